@@ -0,0 +1,167 @@
+package jsonq
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Create a JsonDocument from a decoded Go value, such as the result of unmarshaling other formats into interface{}. The
+// root must be a map or a slice, and the value is converted to the blob model used by JsonDocument:
+//
+// 1. maps become map[string]interface{}, whose keys are converted by the rules below, and the keys colliding after the
+// conversion are reported as an error;
+//
+// 2. slices and arrays become []interface{}, except that []byte becomes a base64 (standard encoding) string;
+//
+// 3. all integers and floats become float64, so the integers whose absolute values are larger than 2^53 lose precision
+// just like unmarshaling json;
+//
+// 4. time.Time becomes an RFC 3339 string with nanoseconds in UTC, so that the result does not depend on the local time
+// zone, and the local date, time and date-time of TOML become "2006-01-02", "15:04:05" and "2006-01-02T15:04:05" strings;
+//
+// 5. big.Int becomes float64;
+//
+// 6. nil pointers become null, and other pointers are dereferenced;
+//
+// 7. map keys of strings are kept, bool, integer and float keys are formatted like json ("true", "1", "1.5"), null keys
+// become "null", time keys are formatted like time values, and other keys become their canonical json.
+func NewJsonDocumentFromValue(v interface{}) (*JsonDocument, error) {
+	blob, err := convertValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	switch blob.(type) {
+	case map[string]interface{}, []interface{}:
+		return &JsonDocument{blob: blob}, nil
+	}
+	return nil, fmt.Errorf("Expected a map or a slice as the root value, got %T\n", v)
+}
+
+// Convert a Go value to a blob.
+func convertValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.CanInterface() {
+		switch val := v.Interface().(type) {
+		case time.Time:
+			return formatTime(val), nil
+		case big.Int:
+			f, _ := new(big.Float).SetInt(&val).Float64()
+			return f, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return convertValue(v.Elem())
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bs := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bs), v)
+			return base64.StdEncoding.EncodeToString(bs), nil
+		}
+		arr := make([]interface{}, v.Len())
+		for idx := range arr {
+			val, err := convertValue(v.Index(idx))
+			if err != nil {
+				return nil, err
+			}
+			arr[idx] = val
+		}
+		return arr, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return convertMap(v)
+	}
+	return nil, fmt.Errorf("Unsupported value %v of type %s\n", v.Interface(), v.Type())
+}
+
+// Convert a Go map to an object, the keys are converted to strings.
+func convertMap(v reflect.Value) (interface{}, error) {
+	obj := make(map[string]interface{}, v.Len())
+	origins := make(map[string][]string, v.Len()) // converted key -> original keys
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := convertKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		val, err := convertValue(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = val
+		origins[key] = append(origins[key], fmt.Sprintf("%#v", iter.Key().Interface()))
+	}
+	for key, keys := range origins {
+		if len(keys) > 1 {
+			sort.Strings(keys)
+			return nil, fmt.Errorf("Map keys %s collide as \"%s\" after conversion\n", strings.Join(keys, ", "), key)
+		}
+	}
+	return obj, nil
+}
+
+// Convert a Go map key to a string.
+func convertKey(v reflect.Value) (string, error) {
+	val, err := convertValue(v)
+	if err != nil {
+		return "", err
+	}
+	switch val := val.(type) {
+	case string:
+		return val, nil
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case float64:
+		if s, err := formatCanonicalNumber(val); err == nil {
+			return s, nil
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64), nil // Inf and NaN
+	}
+	bs, err := Canonicalize(val)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// Format a time to a string in UTC, the local date, time and date-time of TOML are formatted without time zones.
+func formatTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
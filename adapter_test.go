@@ -0,0 +1,46 @@
+package jsonq
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"testing"
+	"time"
+)
+
+func TestNewJsonDocumentFromValue(t *testing.T) {
+	s := "s"
+	doc, err := NewJsonDocumentFromValue(map[interface{}]interface{}{
+		"a":   []int{1, 2},
+		1:     int8(-3),
+		1.5:   uint64(4),
+		true:  []byte("hi"),
+		nil:   &s,
+		"nil": (*string)(nil),
+		"t":   time.Date(2001, 12, 14, 21, 59, 43, 100, time.FixedZone("", -5*3600)),
+		"m":   map[int]float32{2: 0.5},
+	})
+	xtesting.Equal(t, err, nil)
+	jq := NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.Select("a")), []interface{}{1., 2.})
+	xtesting.Equal(t, handle(jq.Select("1")), -3.)
+	xtesting.Equal(t, handle(jq.Select("1.5")), 4.)
+	xtesting.Equal(t, handle(jq.Select("true")), "aGk=")
+	xtesting.Equal(t, handle(jq.Select("null")), "s")
+	xtesting.Equal(t, handle(jq.Select("nil")), nil)
+	xtesting.Equal(t, handle(jq.Select("t")), "2001-12-15T02:59:43.0000001Z")
+	xtesting.Equal(t, handle(jq.Select("m", "2")), 0.5)
+
+	// the times are formatted in UTC regardless of the local time zone
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*3600)
+	defer func() { time.Local = local }()
+	doc, err = NewJsonDocumentFromValue([]interface{}{time.Unix(1008367183, 0)})
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, handle(NewJsonQuery(doc).Select(0)), "2001-12-14T21:59:43Z")
+
+	_, err = NewJsonDocumentFromValue(map[interface{}]interface{}{1: 1, "1": 2})
+	xtesting.Equal(t, err.Error(), "Map keys \"1\", 1 collide as \"1\" after conversion\n")
+	_, err = NewJsonDocumentFromValue("a")
+	xtesting.NotEqual(t, err, nil)
+	_, err = NewJsonDocumentFromValue([]interface{}{make(chan int)})
+	xtesting.NotEqual(t, err, nil)
+}
@@ -0,0 +1,245 @@
+package jsonq
+
+// Aggregate functions which could be used in Func and after "|" in selector.
+var aggregates = map[string]func(members []interface{}) (interface{}, error){
+	"count":    aggregateCount,
+	"sum":      aggregateSum,
+	"avg":      aggregateAvg,
+	"min":      aggregateMin,
+	"max":      aggregateMax,
+	"distinct": aggregateDistinct,
+	"first":    aggregateFirst,
+	"last":     aggregateLast,
+}
+
+func isAggregate(name string) bool {
+	_, ok := aggregates[name]
+	return ok
+}
+
+func aggregate(name string, members []interface{}) (interface{}, error) {
+	fn, ok := aggregates[name]
+	if !ok {
+		return nil, kindErrorf(ErrSyntax, "Unknown function \"%s\"\n", name)
+	}
+	return fn(members)
+}
+
+func aggregateCount(members []interface{}) (interface{}, error) {
+	return float64(len(members)), nil
+}
+
+func aggregateSum(members []interface{}) (interface{}, error) {
+	sum := 0.
+	for _, m := range members {
+		f, ok := numberToFloat64(m)
+		if !ok {
+			return nil, kindErrorf(ErrTypeMismatch, "Function sum on non-number %v\n", m)
+		}
+		sum += f
+	}
+	return sum, nil
+}
+
+func aggregateAvg(members []interface{}) (interface{}, error) {
+	if len(members) == 0 {
+		return nil, kindErrorf(ErrNotFound, "Function avg on empty results\n")
+	}
+	sum := 0.
+	for _, m := range members {
+		f, ok := numberToFloat64(m)
+		if !ok {
+			return nil, kindErrorf(ErrTypeMismatch, "Function avg on non-number %v\n", m)
+		}
+		sum += f
+	}
+	return sum / float64(len(members)), nil
+}
+
+func aggregateMin(members []interface{}) (interface{}, error) {
+	return aggregateExtreme("min", members, func(a, b float64) bool { return a < b })
+}
+
+func aggregateMax(members []interface{}) (interface{}, error) {
+	return aggregateExtreme("max", members, func(a, b float64) bool { return a > b })
+}
+
+func aggregateExtreme(name string, members []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	if len(members) == 0 {
+		return nil, kindErrorf(ErrNotFound, "Function %s on empty results\n", name)
+	}
+	out := 0.
+	for idx, m := range members {
+		f, ok := numberToFloat64(m)
+		if !ok {
+			return nil, kindErrorf(ErrTypeMismatch, "Function %s on non-number %v\n", name, m)
+		}
+		if idx == 0 || better(f, out) {
+			out = f
+		}
+	}
+	return out, nil
+}
+
+func aggregateDistinct(members []interface{}) (interface{}, error) {
+	out := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, m := range members {
+		hash, err := Hash(m)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			out = append(out, m) // keep the first one
+		}
+	}
+	return out, nil
+}
+
+func aggregateFirst(members []interface{}) (interface{}, error) {
+	if len(members) == 0 {
+		return nil, kindErrorf(ErrNotFound, "Function first on empty results\n")
+	}
+	return members[0], nil
+}
+
+func aggregateLast(members []interface{}) (interface{}, error) {
+	if len(members) == 0 {
+		return nil, kindErrorf(ErrNotFound, "Function last on empty results\n")
+	}
+	return members[len(members)-1], nil
+}
+
+// Append a funcToken to the given tokens without modifying the caller's slice.
+func withFunc(tokens []interface{}, name string) []interface{} {
+	out := make([]interface{}, len(tokens), len(tokens)+1)
+	copy(out, tokens)
+	return append(out, Func(name))
+}
+
+func (j *JsonQuery) selectWithFunc(name string, tokens []interface{}) (interface{}, error) {
+	return j.Select(withFunc(tokens, name)...)
+}
+
+func (j *JsonQuery) selectBySelectorWithFunc(name string, selectorString string) (interface{}, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Select(withFunc(selector, name)...)
+}
+
+// ===========================================================================
+
+func (j *JsonQuery) Count(tokens ...interface{}) (int, error) {
+	res, err := j.selectWithFunc("count", tokens)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.(float64)), nil
+}
+
+func (j *JsonQuery) CountBySelector(selectorString string) (int, error) {
+	res, err := j.selectBySelectorWithFunc("count", selectorString)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.(float64)), nil
+}
+
+func (j *JsonQuery) Sum(tokens ...interface{}) (float64, error) {
+	res, err := j.selectWithFunc("sum", tokens)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) SumBySelector(selectorString string) (float64, error) {
+	res, err := j.selectBySelectorWithFunc("sum", selectorString)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) Avg(tokens ...interface{}) (float64, error) {
+	res, err := j.selectWithFunc("avg", tokens)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) AvgBySelector(selectorString string) (float64, error) {
+	res, err := j.selectBySelectorWithFunc("avg", selectorString)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) Min(tokens ...interface{}) (float64, error) {
+	res, err := j.selectWithFunc("min", tokens)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) MinBySelector(selectorString string) (float64, error) {
+	res, err := j.selectBySelectorWithFunc("min", selectorString)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) Max(tokens ...interface{}) (float64, error) {
+	res, err := j.selectWithFunc("max", tokens)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) MaxBySelector(selectorString string) (float64, error) {
+	res, err := j.selectBySelectorWithFunc("max", selectorString)
+	if err != nil {
+		return 0, err
+	}
+	return interfaceToFloat64(res)
+}
+
+func (j *JsonQuery) Distinct(tokens ...interface{}) ([]interface{}, error) {
+	res, err := j.selectWithFunc("distinct", tokens)
+	if err != nil {
+		return nil, err
+	}
+	return interfaceToArray(res)
+}
+
+func (j *JsonQuery) DistinctBySelector(selectorString string) ([]interface{}, error) {
+	res, err := j.selectBySelectorWithFunc("distinct", selectorString)
+	if err != nil {
+		return nil, err
+	}
+	return interfaceToArray(res)
+}
+
+func (j *JsonQuery) First(tokens ...interface{}) (interface{}, error) {
+	return j.selectWithFunc("first", tokens)
+}
+
+func (j *JsonQuery) FirstBySelector(selectorString string) (interface{}, error) {
+	return j.selectBySelectorWithFunc("first", selectorString)
+}
+
+func (j *JsonQuery) Last(tokens ...interface{}) (interface{}, error) {
+	return j.selectWithFunc("last", tokens)
+}
+
+func (j *JsonQuery) LastBySelector(selectorString string) (interface{}, error) {
+	return j.selectBySelectorWithFunc("last", selectorString)
+}
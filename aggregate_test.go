@@ -0,0 +1,81 @@
+package jsonq
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+	"unsafe"
+)
+
+func TestAggregate(t *testing.T) {
+	bytes := *(*[]byte)(unsafe.Pointer(&objDoc))
+	doc, err := NewJsonDocument(bytes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	jq := NewJsonQuery(doc)
+	val1 := handle(jq.SelectBySelector("c f * g | sum"))
+	val2 := handle(jq.SelectBySelector("c f * g | count"))
+	val3 := handle(jq.SelectBySelector("c f * h | avg"))
+	val4 := handle(jq.SelectBySelector("c j l * * | max"))
+	val5 := handle(jq.SelectBySelector("c j l #0 | min"))
+	val6 := handle(jq.SelectBySelector("c f * i | last"))
+	val7 := handle(jq.SelectBySelector("c j l * #0 | first"))
+	val8 := handle(jq.SelectBySelector("c j l #0 | distinct | count"))
+	val9 := handle(jq.Select("c", "f", All(), "g", Func("max")))
+
+	xtesting.Equal(t, val1, 1368.)
+	xtesting.Equal(t, val2, 3.)
+	xtesting.Equal(t, val3, 0.6)
+	xtesting.Equal(t, val4, 6.)
+	xtesting.Equal(t, val5, 1.)
+	xtesting.Equal(t, val6, "ghi")
+	xtesting.Equal(t, val7, 1.)
+	xtesting.Equal(t, val8, 3.)
+	xtesting.Equal(t, val9, 789.)
+
+	val11 := handle(jq.Count("c", "f"))
+	val12 := handle(jq.SumBySelector("c j l #1"))
+	val13 := handle(jq.Avg("c", "j", "l", 1))
+	val14 := handle(jq.MinBySelector("c f * g"))
+	val15 := handle(jq.Max("c", "f", All(), "h"))
+	val16 := handle(jq.Distinct("c", "f", All(), Multi("g", "g")))
+	val17 := handle(jq.FirstBySelector("c f * i"))
+	val18 := handle(jq.Last("c", "f", All(), "i"))
+	val19 := handle(jq.CountBySelector("c j"))
+
+	xtesting.Equal(t, val11, 3)
+	xtesting.Equal(t, val12, 15.)
+	xtesting.Equal(t, val13, 5.)
+	xtesting.Equal(t, val14, 123.)
+	xtesting.Equal(t, val15, 0.9)
+	xtesting.Equal(t, val16, []interface{}{123., 456., 789.})
+	xtesting.Equal(t, val17, "abc")
+	xtesting.Equal(t, val18, "ghi")
+	xtesting.Equal(t, val19, 2)
+
+	// the fields of an object are aggregated in the order of keys
+	doc, err = NewJsonDocument([]byte(`{"d": 4, "b": 2, "a": 1, "c": 3, "e": 1}`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for i := 0; i < 10; i++ {
+		xtesting.Equal(t, handle(NewJsonQuery(doc).SelectBySelector("* | first")), 1.)
+		xtesting.Equal(t, handle(NewJsonQuery(doc).SelectBySelector("* | last")), 1.)
+		xtesting.Equal(t, handle(NewJsonQuery(doc).SelectBySelector("* | distinct")), []interface{}{1., 2., 3., 4.})
+	}
+
+	_, err = jq.SumBySelector("c f * i")
+	xtesting.NotEqual(t, err, nil)
+	_, err = jq.Avg("c", "f", All(), "x")
+	xtesting.NotEqual(t, err, nil)
+	_, err = jq.SelectBySelector("c f | median")
+	xtesting.NotEqual(t, err, nil)
+	_, err = jq.SelectBySelector("c f * g | sum+count")
+	xtesting.NotEqual(t, err, nil)
+	_, err = jq.SelectBySelector("c f * g |")
+	xtesting.NotEqual(t, err, nil)
+	_, err = jq.Select("c", "e", Func("count"))
+	xtesting.NotEqual(t, err, nil)
+}
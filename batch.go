@@ -0,0 +1,169 @@
+package jsonq
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A compiled batch of named selectors, the selectors are merged into a prefix tree, so that the shared prefixes are
+// evaluated only once in a single traversal.
+type Batch struct {
+	root  *batchNode
+	names []string        // sorted names
+	paths [][]interface{} // tokens of the selectors, used to decode lazy documents partly
+}
+
+// A node of the prefix tree, it represents the tokens from the root to the node.
+type batchNode struct {
+	token    interface{}
+	children []*batchNode
+	names    []string // names of the selectors ending at the node
+}
+
+// Compile named selector strings to a Batch, the error of a selector with a syntax error contains its name.
+func CompileBatch(selectors map[string]string) (*Batch, error) {
+	names := make([]string, 0, len(selectors))
+	for name := range selectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := &Batch{root: &batchNode{}, names: names}
+	for _, name := range names {
+		tokens, err := _NewParser(selectors[name]).Parse()
+		if err != nil {
+			return nil, kindErrorf(ErrSyntax, "Selector \"%s\" of \"%s\": %v", selectors[name], name, err)
+		}
+		b.paths = append(b.paths, tokens)
+		node := b.root
+		for _, token := range tokens {
+			node = node.child(token)
+		}
+		node.names = append(node.names, name)
+	}
+	return b, nil
+}
+
+// Compile named selector strings to a Batch, it panics if a selector string has a syntax error.
+func MustCompileBatch(selectors map[string]string) *Batch {
+	b, err := CompileBatch(selectors)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Get the names of the selectors in the batch, in sorted order.
+func (b *Batch) Names() []string {
+	out := make([]string, len(b.names))
+	copy(out, b.names)
+	return out
+}
+
+// Get the child of the token, or create it if it does not exist.
+func (n *batchNode) child(token interface{}) *batchNode {
+	key := batchTokenKey(token)
+	for _, child := range n.children {
+		if batchTokenKey(child.token) == key {
+			return child
+		}
+	}
+	child := &batchNode{token: token}
+	n.children = append(n.children, child)
+	return child
+}
+
+// Get the names of the selectors ending at the node and its descendants.
+func (n *batchNode) allNames() []string {
+	names := append([]string(nil), n.names...)
+	for _, child := range n.children {
+		names = append(names, child.allNames()...)
+	}
+	return names
+}
+
+// Get a key of a token, the tokens with the same key select the same values.
+func batchTokenKey(token interface{}) string {
+	switch tok := token.(type) {
+	case int:
+		return "#" + strconv.Itoa(tok)
+	case string:
+		return "." + tok
+	case *multiToken:
+		keys := make([]string, len(tok.sels))
+		for idx, sel := range tok.sels {
+			keys[idx] = batchTokenKey(sel)
+		}
+		return "+(" + strings.Join(keys, ",") + ")"
+	case *starToken:
+		return "*"
+	case *funcToken:
+		return "|" + tok.name
+	case fmt.Stringer: // keyGlobToken, keyRegexToken and sliceToken
+		return fmt.Sprintf("%T%s", tok, tok.String())
+	}
+	return fmt.Sprintf("%T%p", token, token)
+}
+
+// The results of SelectBatch, each selector has either a value or an error.
+type BatchResult struct {
+	Values map[string]interface{} // the values of the selectors, the same as Select
+	Errors map[string]error       // the errors of the selectors, the same as Select
+}
+
+// Get the value or the error of a selector by its name.
+func (r *BatchResult) Get(name string) (interface{}, error) {
+	if err, ok := r.Errors[name]; ok {
+		return nil, err
+	}
+	if val, ok := r.Values[name]; ok {
+		return val, nil
+	}
+	return nil, fmt.Errorf("Selector \"%s\" is not in the batch\n", name)
+}
+
+// Query json by all the selectors in a Batch in a single traversal, the result of each selector is the same as Select.
+// Only the parts needed by the selectors are decoded if the document is lazy.
+func (j *JsonQuery) SelectBatch(batch *Batch) *BatchResult {
+	result := &BatchResult{Values: make(map[string]interface{}), Errors: make(map[string]error)}
+	blob := j.blobForPaths(batch.paths)
+	for _, name := range batch.root.names { // empty selectors
+		result.Values[name] = blob
+	}
+	j.selectBatch(batch.root, []interface{}{blob}, false, result)
+	return result
+}
+
+// Query json by named selector strings in a single traversal, see CompileBatch and SelectBatch.
+func (j *JsonQuery) SelectBatchBySelectors(selectors map[string]string) (*BatchResult, error) {
+	batch, err := CompileBatch(selectors)
+	if err != nil {
+		return nil, err
+	}
+	return j.SelectBatch(batch), nil
+}
+
+// Evaluate the children of a node from the values of the node.
+func (j *JsonQuery) selectBatch(node *batchNode, vals []interface{}, isArray bool, result *BatchResult) {
+	for _, child := range node.children {
+		cur := make([]interface{}, len(vals)) // rstep replaces the values of single tokens in place
+		copy(cur, vals)
+		cur, multi, err := j.rstep(cur, isArray, child.token)
+		if err != nil {
+			for _, name := range child.allNames() {
+				result.Errors[name] = err
+			}
+			continue
+		}
+		for _, name := range child.names {
+			if multi {
+				result.Values[name] = append([]interface{}(nil), cur...) // not shared with the other names
+			} else {
+				result.Values[name] = cur[0]
+			}
+		}
+		j.selectBatch(child, cur, multi, result)
+	}
+}
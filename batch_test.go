@@ -0,0 +1,80 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(objDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	selectors := map[string]string{
+		"root":   "",
+		"a":      "a",
+		"e":      "c e",
+		"g0":     "c f #0 g",
+		"gs":     "c f * g",
+		"g_last": "c f #-1 g",
+		"h_sum":  "c f * h | sum",
+		"is":     "c f #0:2 i",
+		"multi":  "c f #0+#2 i",
+		"jk":     "c j k",
+		"l":      "c j l #1",
+		"pat":    "c ~/^[ef]$/",
+		"glob":   "c j ~?",
+		"cnt":    "c f | count",
+		"miss":   "c x",
+		"miss2":  "c x y",
+		"bad":    "a #0",
+		"oob":    "c f #5 g",
+	}
+	batch := MustCompileBatch(selectors)
+	xtesting.Equal(t, len(batch.Names()), len(selectors))
+	xtesting.Equal(t, batch.Names()[0], "a")
+
+	result := jq.SelectBatch(batch)
+	xtesting.Equal(t, len(result.Values)+len(result.Errors), len(selectors))
+	for name, selector := range selectors {
+		want, wantErr := jq.SelectBySelector(selector)
+		val, err := result.Get(name)
+		xtesting.Equal(t, val, want)
+		xtesting.Equal(t, err, wantErr)
+	}
+	xtesting.Equal(t, errors.Is(result.Errors["miss2"], ErrNotFound), true)
+	xtesting.Equal(t, errors.Is(result.Errors["bad"], ErrTypeMismatch), true)
+	xtesting.Equal(t, result.Values["gs"], []interface{}{123., 456., 789.})
+	_, err = result.Get("x")
+	xtesting.NotEqual(t, err, nil)
+
+	// shared prefixes are merged
+	xtesting.Equal(t, len(batch.root.children), 2)             // a, c
+	xtesting.Equal(t, len(batch.root.children[1].children), 5) // e, f, j, pattern, x
+	gs := result.Values["gs"].([]interface{})
+	gs[0] = nil
+	xtesting.Equal(t, handle(jq.SelectBySelector("c f #0 g")), 123.)
+
+	// lazy documents, query options and errors
+	doc, _ = NewJsonDocumentWithOptions([]byte(`{"A": {"B": [1, 2]}}`), ParseOptions{Lazy: true})
+	result, err = NewJsonQuery(doc, WithCaseInsensitiveKeys()).SelectBatchBySelectors(map[string]string{"b0": "a b #0", "b": "a b | sum"})
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, result.Values, map[string]interface{}{"b0": 1., "b": 3.})
+	_, err = jq.SelectBatchBySelectors(map[string]string{"ok": "a", "bad": "a |"})
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	xtesting.Equal(t, err.Error()[:24], "Selector \"a |\" of \"bad\":")
+}
+
+func BenchmarkSelectBatch(b *testing.B) {
+	doc, _ := NewJsonDocument([]byte(objDoc))
+	jq := NewJsonQuery(doc)
+	batch := MustCompileBatch(map[string]string{"g0": "c f #0 g", "g1": "c f #1 g", "g2": "c f #2 g", "k": "c j k", "l": "c j l #1 #2"})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = jq.SelectBatch(batch)
+	}
+}
@@ -0,0 +1,250 @@
+package jsonq
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Check if two json values (such as the results of JsonQuery.Select) are deeply equal.
+//
+// Object keys are compared regardless of their order, and numbers are compared by value
+// regardless of their go type (float64 / int64 / int) or their formatting in the source.
+func Equal(a, b interface{}) bool {
+	if fa, ok := numberToFloat64(a); ok {
+		fb, ok := numberToFloat64(b)
+		return ok && fa == fb
+	}
+
+	switch va := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		vb, ok := b.(bool)
+		return ok && va == vb
+	case string:
+		vb, ok := b.(string)
+		return ok && va == vb
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for idx := range va {
+			if !Equal(va[idx], vb[idx]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, v := range va {
+			w, ok := vb[k]
+			if !ok || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Canonicalize a json value to bytes, following the JSON Canonicalization Scheme (RFC 8785).
+//
+// Object keys are sorted by their UTF-16 code units, numbers are serialized in the ECMAScript
+// format, and strings are escaped minimally, so that equal values always have equal bytes.
+func Canonicalize(i interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := canonicalize(buf, i)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Hash a json value by the sha256 of its canonical form, returns a hex string which can be used as map keys and cache keys.
+func Hash(i interface{}) (string, error) {
+	bs, err := Canonicalize(i)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Check if two documents are deeply equal, see Equal.
+func (d *JsonDocument) Equal(other *JsonDocument) bool {
+	return Equal(d.value(), other.value())
+}
+
+// Canonicalize the whole document, see Canonicalize.
+func (d *JsonDocument) Canonicalize() ([]byte, error) {
+	return Canonicalize(d.value())
+}
+
+// Hash the whole document, see Hash.
+func (d *JsonDocument) Hash() (string, error) {
+	return Hash(d.value())
+}
+
+func canonicalize(buf *bytes.Buffer, i interface{}) error {
+	if f, ok := numberToFloat64(i); ok {
+		s, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+		return nil
+	}
+
+	switch v := i.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for idx, item := range v {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			if err := canonicalize(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUtf16(keys[i], keys[j])
+		})
+		buf.WriteByte('{')
+		for idx, k := range keys {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := canonicalize(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("Could not canonicalize non-json value %v\n", i)
+	}
+	return nil
+}
+
+// Convert a go number to float64, the second return value is false if i is not a number.
+func numberToFloat64(i interface{}) (float64, bool) {
+	switch v := i.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Format a number in the ECMAScript Number.prototype.toString format.
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("Could not canonicalize number %v\n", f)
+	}
+	if f == 0 {
+		return "0", nil // also for -0
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// 1e-07 -> 1e-7
+		n := len(s)
+		if n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s, nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString("\\\"")
+		case '\\':
+			buf.WriteString("\\\\")
+		case '\b':
+			buf.WriteString("\\b")
+		case '\f':
+			buf.WriteString("\\f")
+		case '\n':
+			buf.WriteString("\\n")
+		case '\r':
+			buf.WriteString("\\r")
+		case '\t':
+			buf.WriteString("\\t")
+		default:
+			if r < 0x20 {
+				buf.WriteString("\\u00")
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// Compare two strings by their UTF-16 code units.
+func lessUtf16(a, b string) bool {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			ua, ub := utf16Units(ra), utf16Units(rb)
+			for idx := 0; idx < len(ua) && idx < len(ub); idx++ {
+				if ua[idx] != ub[idx] {
+					return ua[idx] < ub[idx]
+				}
+			}
+			return len(ua) < len(ub)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) < len(b)
+}
+
+func utf16Units(r rune) []uint16 {
+	if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+		return []uint16{uint16(r1), uint16(r2)}
+	}
+	return []uint16{uint16(r)}
+}
@@ -0,0 +1,57 @@
+package jsonq
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	doc1, err := NewJsonDocument([]byte(`{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	val1 := handle(doc1.Canonicalize()).([]byte)
+	xtesting.Equal(t, string(val1), `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`)
+
+	doc2, err := NewJsonDocument([]byte(`{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	val2 := handle(doc2.Canonicalize()).([]byte)
+	xtesting.Equal(t, string(val2), "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}")
+
+	for f, s := range map[float64]string{
+		0: "0", 1: "1", -1.5: "-1.5", 1e21: "1e+21", 1e20: "100000000000000000000", 1e-6: "0.000001", 1e-7: "1e-7",
+		123456789012345680000: "123456789012345680000", 9007199254740993: "9007199254740992", 5e-324: "5e-324",
+	} {
+		val := handle(formatCanonicalNumber(f))
+		xtesting.Equal(t, val, s)
+	}
+
+	_, err = Canonicalize(map[string]interface{}{"a": struct{}{}})
+	xtesting.NotEqual(t, err, nil)
+}
+
+func TestEqualAndHash(t *testing.T) {
+	doc1 := handle(NewJsonDocument([]byte(`{"a": [1, 2.0, {"b": null, "c": "d"}], "e": true}`))).(*JsonDocument)
+	doc2 := handle(NewJsonDocument([]byte(`{"e": true, "a": [1.0, 2e0, {"c": "d", "b": null}]}`))).(*JsonDocument)
+	doc3 := handle(NewJsonDocument([]byte(`{"e": true, "a": [1.0, 2e0, {"c": "d", "b": false}]}`))).(*JsonDocument)
+
+	xtesting.Equal(t, doc1.Equal(doc2), true)
+	xtesting.Equal(t, doc1.Equal(doc3), false)
+	xtesting.Equal(t, Equal(1., int64(1)), true)
+	xtesting.Equal(t, Equal(nil, 0.), false)
+	xtesting.Equal(t, Equal([]interface{}{1.}, []interface{}{1., 2.}), false)
+
+	hash1 := handle(doc1.Hash())
+	hash2 := handle(doc2.Hash())
+	hash3 := handle(doc3.Hash())
+	xtesting.Equal(t, hash1, hash2)
+	xtesting.NotEqual(t, hash1, hash3)
+	xtesting.Equal(t, len(hash1.(string)), 64)
+
+	val1 := handle(NewJsonQuery(doc1).Select("a", 2))
+	val2 := handle(NewJsonQuery(doc2).Select("a", -1))
+	xtesting.Equal(t, Equal(val1, val2), true)
+}
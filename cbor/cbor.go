@@ -0,0 +1,81 @@
+// Package cbor creates json documents of jsonq from CBOR (RFC 8949), so that CBOR could be queried by the same
+// selectors.
+package cbor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Aoi-hosizora/jsonq"
+	cborv2 "github.com/fxamacker/cbor/v2"
+)
+
+// Create a JsonDocument from CBOR, the values are converted by jsonq.NewJsonDocumentFromValue:
+//
+// 1. unsigned and negative integers, bignums (tag 2 and 3) and half, single and double floats become float64;
+//
+// 2. byte strings become base64 (standard encoding) strings, and text strings are kept as strings;
+//
+// 3. date-times (tag 0 and 1) become RFC 3339 strings in UTC, and other tags are replaced by their contents;
+//
+// 4. null and undefined become null, and other simple values are reported as an error;
+//
+// 5. map keys of any type are converted to strings like jsonq.NewJsonDocumentFromValue, such as 1 to "1" and h'0102'
+// to "AQI=".
+func NewJsonDocument(data []byte) (*jsonq.JsonDocument, error) {
+	var v interface{}
+	if err := cborv2.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	v, err := convertValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonq.NewJsonDocumentFromValue(v)
+}
+
+// Convert the CBOR specific values (byte strings, tags and simple values), the others are left to
+// jsonq.NewJsonDocumentFromValue.
+func convertValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case cborv2.ByteString:
+		return base64.StdEncoding.EncodeToString([]byte(val)), nil
+	case cborv2.Tag:
+		return convertValue(val.Content)
+	case cborv2.SimpleValue:
+		return nil, fmt.Errorf("Unsupported CBOR simple value %d\n", val)
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for idx, item := range val {
+			item, err := convertValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr[idx] = item
+		}
+		return arr, nil
+	case map[interface{}]interface{}:
+		obj := make(map[interface{}]interface{}, len(val))
+		origins := make(map[interface{}]string, len(val)) // converted key -> original key
+		for key, item := range val {
+			newKey, err := convertValue(key)
+			if err != nil {
+				return nil, err
+			}
+			if origin, ok := origins[newKey]; ok {
+				keys := []string{origin, fmt.Sprintf("%#v", key)}
+				sort.Strings(keys)
+				return nil, fmt.Errorf("Map keys %s collide as %#v after conversion\n", strings.Join(keys, ", "), newKey)
+			}
+			item, err = convertValue(item)
+			if err != nil {
+				return nil, err
+			}
+			obj[newKey], origins[newKey] = item, fmt.Sprintf("%#v", key)
+		}
+		return obj, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,51 @@
+package cbor
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	cborv2 "github.com/fxamacker/cbor/v2"
+	"log"
+	"math/big"
+	"testing"
+)
+
+func handle(obj interface{}, err error) interface{} {
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return obj
+}
+
+func TestNewJsonDocument(t *testing.T) {
+	bigInt, _ := new(big.Int).SetString("18446744073709551616", 10)
+	data, err := cborv2.Marshal(map[interface{}]interface{}{
+		"neg":                  -3,
+		"big":                  bigInt,
+		"bytes":                []byte{1, 2},
+		uint64(1):              "one",
+		cborv2.ByteString("k"): "byte key",
+		"time":                 cborv2.Tag{Number: 0, Content: "2001-12-14T21:59:43Z"},
+		"tag":                  cborv2.Tag{Number: 32, Content: "http://a/b"},
+		"arr":                  []interface{}{"a", nil, 1.5},
+	})
+	xtesting.Equal(t, err, nil)
+	doc, err := NewJsonDocument(data)
+	xtesting.Equal(t, err, nil)
+	jq := jsonq.NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.Select("neg")), -3.)
+	xtesting.Equal(t, handle(jq.Select("big")), 18446744073709551616.)
+	xtesting.Equal(t, handle(jq.Select("bytes")), "AQI=")
+	xtesting.Equal(t, handle(jq.Select("1")), "one")
+	xtesting.Equal(t, handle(jq.Select("aw==")), "byte key")
+	xtesting.Equal(t, handle(jq.Select("time")), "2001-12-14T21:59:43Z")
+	xtesting.Equal(t, handle(jq.Select("tag")), "http://a/b")
+	xtesting.Equal(t, handle(jq.Select("arr")), []interface{}{"a", nil, 1.5})
+
+	_, err = NewJsonDocument([]byte{0x82, 0x01})
+	xtesting.NotEqual(t, err, nil)
+	_, err = NewJsonDocument([]byte{0x81, 0xf0}) // [simple(16)]
+	xtesting.NotEqual(t, err, nil)
+	data, _ = cborv2.Marshal(map[interface{}]interface{}{"aw==": 1, cborv2.ByteString("k"): 2})
+	_, err = NewJsonDocument(data)
+	xtesting.Equal(t, err.Error(), "Map keys \"aw==\", \"k\" collide as \"aw==\" after conversion\n")
+}
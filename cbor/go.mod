@@ -0,0 +1,13 @@
+module github.com/Aoi-hosizora/jsonq/cbor
+
+go 1.17
+
+require (
+	github.com/Aoi-hosizora/ahlib v1.3.0
+	github.com/Aoi-hosizora/jsonq v0.0.0
+	github.com/fxamacker/cbor/v2 v2.7.0
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
+
+replace github.com/Aoi-hosizora/jsonq => ../
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Aoi-hosizora/jsonq"
+	"github.com/Aoi-hosizora/jsonq/schema"
+)
+
+// Run the gen subcommand, which generates Go types from the sample files.
+func runGen(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts := schema.GoOptions{}
+	fs := flag.NewFlagSet("jsonq gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.Package, "package", "", "the package clause of the generated code")
+	fs.StringVar(&opts.Name, "name", "Root", "the name of the root type")
+	fs.BoolVar(&opts.JsonqTags, "jsonq", false, "add jsonq tags of the selectors from the root")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: jsonq gen [flags] [file ...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err != flag.ErrHelp {
+			printError(stderr, "jsonq: ", err)
+		}
+		return exitSyntax
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	docs := make([]*jsonq.JsonDocument, 0, len(files))
+	for _, file := range files {
+		doc, ok := readDocument(file, stdin, stderr)
+		if !ok {
+			return exitError
+		}
+		docs = append(docs, doc)
+	}
+
+	code, err := schema.GenerateGo(opts, docs...)
+	if err != nil {
+		printError(stderr, "jsonq: ", err)
+		return exitError
+	}
+	if _, err := stdout.Write(code); err != nil {
+		printError(stderr, "jsonq: ", err)
+		return exitError
+	}
+	return exitOk
+}
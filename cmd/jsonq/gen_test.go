@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGen(t *testing.T) {
+	code, out, _ := runTest([]string{"gen", "-package", "model", "-jsonq"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "package model\n\n"+
+		"type Root struct {\n"+
+		"\tA string `json:\"a\" jsonq:\"a\"`\n"+
+		"\tC C      `json:\"c\" jsonq:\"c\"`\n"+
+		"}\n\n"+
+		"type C struct {\n"+
+		"\tF []FItem `json:\"f\" jsonq:\"c f\"`\n"+
+		"}\n\n"+
+		"type FItem struct {\n"+
+		"\tG int64  `json:\"g\" jsonq:\"c f * g\"`\n"+
+		"\tI string `json:\"i\" jsonq:\"c f * i\"`\n"+
+		"}\n")
+
+	dir, err := ioutil.TempDir("", "jsonq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file1, file2 := filepath.Join(dir, "1.json"), filepath.Join(dir, "2.json")
+	_ = ioutil.WriteFile(file1, []byte(`[{"a": 1}]`), 0644)
+	_ = ioutil.WriteFile(file2, []byte(`[{"a": null, "b": "x"}]`), 0644)
+	code, out, _ = runTest([]string{"gen", "-name", "Feed", file1, file2}, "")
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "type Feed []FeedItem\n\n"+
+		"type FeedItem struct {\n"+
+		"\tA *int64  `json:\"a\"`\n"+
+		"\tB *string `json:\"b,omitempty\"`\n"+
+		"}\n")
+
+	// a selector of the key "gen"
+	code, out, _ = runTest([]string{"--", "gen"}, `{"gen": 1}`)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "1\n")
+
+	code, _, _ = runTest([]string{"gen", "-x"}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+	code, _, _ = runTest([]string{"gen"}, `{"a": }`)
+	xtesting.Equal(t, code, exitError)
+}
@@ -0,0 +1,219 @@
+// Command jsonq queries json from files or stdin by a jsonq selector, a json path or a json pointer.
+//
+// Usage:
+//
+//	jsonq [flags] selector [file ...]
+//	jsonq [flags] -path $.json.path [file ...]
+//	jsonq [flags] -pointer /json/pointer [file ...]
+//	jsonq [flags] -i file
+//	jsonq gen [-package name] [-name Root] [-jsonq] [file ...]
+//
+// The gen subcommand generates Go types from the sample files, use "jsonq -- gen" to select the key "gen".
+//
+// Exit codes:
+//
+//	0: success
+//	1: other errors, such as reading files or parsing json
+//	2: syntax error of the selector, json path or json pointer, or bad usage
+//	3: the selected field or array index does not exist
+//	4: the selected value has an unexpected type
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Aoi-hosizora/jsonq"
+)
+
+const (
+	exitOk = iota
+	exitError
+	exitSyntax
+	exitNotFound
+	exitTypeMismatch
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// Options parsed from the command line.
+type options struct {
+	path       string
+	pointer    string
+	usePath    bool // -path is set, even if it is empty
+	usePointer bool // -pointer is set, even if it is empty (the root of RFC 6901)
+	raw        bool
+	lines      bool
+	compact    bool
+	repl       bool
+
+	selector string
+	files    []string
+}
+
+func parseOptions(args []string, stderr io.Writer) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("jsonq", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.path, "path", "", "query by a json path (such as $.a.b[0]) instead of a selector")
+	fs.StringVar(&opts.pointer, "pointer", "", "query by a json pointer (such as /a/b/0) instead of a selector")
+	fs.BoolVar(&opts.raw, "r", false, "print strings without quotes")
+	fs.BoolVar(&opts.lines, "l", false, "print the elements of an array result one per line")
+	fs.BoolVar(&opts.compact, "c", false, "print json compactly")
+	fs.BoolVar(&opts.repl, "i", false, "explore the file interactively")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: jsonq [flags] selector [file ...]\n       jsonq [flags] -path|-pointer expr [file ...]\n       jsonq [flags] -i file\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		opts.usePath = opts.usePath || f.Name == "path"
+		opts.usePointer = opts.usePointer || f.Name == "pointer"
+	})
+
+	rest := fs.Args()
+	if opts.usePath && opts.usePointer {
+		return nil, errors.New("flags -path and -pointer could not be used together")
+	}
+	if opts.repl {
+		if opts.usePath || opts.usePointer || len(rest) != 1 {
+			return nil, errors.New("flag -i requires exactly one file")
+		}
+	} else if !opts.usePath && !opts.usePointer {
+		if len(rest) == 0 {
+			fs.Usage()
+			return nil, errors.New("a selector is required")
+		}
+		opts.selector, rest = rest[0], rest[1:]
+	}
+	opts.files = rest
+	if len(opts.files) == 0 {
+		opts.files = []string{"-"}
+	}
+	return opts, nil
+}
+
+// Run the command and return the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "gen" {
+		return runGen(args[1:], stdin, stdout, stderr)
+	}
+	opts, err := parseOptions(args, stderr)
+	if err != nil {
+		if err != flag.ErrHelp {
+			printError(stderr, "jsonq: ", err)
+		}
+		return exitSyntax
+	}
+
+	for _, file := range opts.files {
+		doc, ok := readDocument(file, stdin, stderr)
+		if !ok {
+			return exitError
+		}
+		if opts.repl {
+			return runRepl(doc, opts, stdout, stderr)
+		}
+
+		res, err := query(jsonq.NewJsonQuery(doc), opts)
+		if err != nil {
+			printError(stderr, "jsonq: "+file+": ", err)
+			return exitCode(err)
+		}
+		if err := output(stdout, res, opts); err != nil {
+			printError(stderr, "jsonq: ", err)
+			return exitError
+		}
+	}
+	return exitOk
+}
+
+// Print an error with a prefix, the message always ends with a single newline.
+func printError(w io.Writer, prefix string, err error) {
+	_, _ = fmt.Fprintf(w, "%s%s\n", prefix, strings.TrimRight(err.Error(), "\n"))
+}
+
+func readInput(file string, stdin io.Reader) ([]byte, error) {
+	if file == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// Read and parse a document, the errors are printed to stderr.
+func readDocument(file string, stdin io.Reader, stderr io.Writer) (*jsonq.JsonDocument, bool) {
+	data, err := readInput(file, stdin)
+	if err != nil {
+		printError(stderr, "jsonq: ", err)
+		return nil, false
+	}
+	doc, err := jsonq.NewJsonDocument(data)
+	if err != nil {
+		printError(stderr, "jsonq: "+file+": ", err)
+		var parseErr *jsonq.ParseError
+		if errors.As(err, &parseErr) {
+			_, _ = fmt.Fprintf(stderr, "%s\n", parseErr.Excerpt)
+		}
+		return nil, false
+	}
+	return doc, true
+}
+
+func query(jq *jsonq.JsonQuery, opts *options) (interface{}, error) {
+	switch {
+	case opts.usePath:
+		return jq.SelectByJsonPath(opts.path)
+	case opts.usePointer:
+		return jq.SelectByJsonPointer(opts.pointer)
+	}
+	return jq.SelectBySelector(opts.selector)
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, jsonq.ErrSyntax):
+		return exitSyntax
+	case errors.Is(err, jsonq.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, jsonq.ErrTypeMismatch):
+		return exitTypeMismatch
+	}
+	return exitError
+}
+
+func output(w io.Writer, res interface{}, opts *options) error {
+	if arr, ok := res.([]interface{}); ok && opts.lines {
+		for _, item := range arr {
+			if err := outputOne(w, item, opts, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return outputOne(w, res, opts, opts.compact)
+}
+
+func outputOne(w io.Writer, val interface{}, opts *options, compact bool) error {
+	if s, ok := val.(string); ok && opts.raw {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(val)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testDoc = `{"a": "b<c>", "c": {"f": [{"g": 123, "i": "abc"}, {"g": 456, "i": "def"}]}}`
+
+func runTest(args []string, stdin string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(args, strings.NewReader(stdin), stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	code, out, _ := runTest([]string{"c f #0 g"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "123\n")
+
+	code, out, _ = runTest([]string{"a"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "\"b<c>\"\n")
+
+	code, out, _ = runTest([]string{"-r", "a"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "b<c>\n")
+
+	code, out, _ = runTest([]string{"c f * i"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "[\n  \"abc\",\n  \"def\"\n]\n")
+
+	code, out, _ = runTest([]string{"-c", "c f #1"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "{\"g\":456,\"i\":\"def\"}\n")
+
+	code, out, _ = runTest([]string{"-l", "-r", "c f * i"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "abc\ndef\n")
+
+	code, out, _ = runTest([]string{"-l", "c f"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "{\"g\":123,\"i\":\"abc\"}\n{\"g\":456,\"i\":\"def\"}\n")
+
+	code, out, _ = runTest([]string{"-path", "$.c.f[*].g"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "[\n  123,\n  456\n]\n")
+
+	code, out, _ = runTest([]string{"-pointer", "/c/f/1/i", "-"}, testDoc)
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "\"def\"\n")
+
+	code, out, _ = runTest([]string{"-c", "-pointer", ""}, `{"a": [1]}`) // the root
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "{\"a\":[1]}\n")
+}
+
+func TestRunError(t *testing.T) {
+	code, _, _ := runTest([]string{"c x"}, testDoc)
+	xtesting.Equal(t, code, exitNotFound)
+
+	code, _, _ = runTest([]string{"c f #5"}, testDoc)
+	xtesting.Equal(t, code, exitNotFound)
+
+	code, _, _ = runTest([]string{"a #0"}, testDoc)
+	xtesting.Equal(t, code, exitTypeMismatch)
+
+	code, _, _ = runTest([]string{"c f * i | sum"}, testDoc)
+	xtesting.Equal(t, code, exitTypeMismatch)
+
+	code, _, _ = runTest([]string{"c |"}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+
+	code, _, _ = runTest([]string{"-path", "c.f"}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+
+	code, _, _ = runTest([]string{}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+
+	code, _, _ = runTest([]string{"-path", "$", "-pointer", "/a"}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+
+	code, _, stderr := runTest([]string{"a"}, "{\n\t\"a\": 1,\n\t\"b\": [1 2]\n}")
+	xtesting.Equal(t, code, exitError)
+	xtesting.Equal(t, stderr, "jsonq: -: Invalid character '2' after array element at line 3, column 10\n\t\"b\": [1 2]\n\t        ^\n")
+
+	code, _, _ = runTest([]string{"a", "not_exist.json"}, "")
+	xtesting.Equal(t, code, exitError)
+
+	code, _, _ = runTest([]string{"-path", "", "-pointer", ""}, testDoc)
+	xtesting.Equal(t, code, exitSyntax)
+
+	// the errors always end with a single newline
+	code, _, stderr = runTest([]string{"c x"}, testDoc)
+	xtesting.Equal(t, code, exitNotFound)
+	xtesting.Equal(t, strings.Count(stderr, "\n"), 1)
+	xtesting.Equal(t, strings.HasSuffix(stderr, "\n"), true)
+	buf := &bytes.Buffer{}
+	printError(buf, "jsonq: ", errors.New("a"))
+	printError(buf, "jsonq: ", errors.New("b\n"))
+	xtesting.Equal(t, buf.String(), "jsonq: a\njsonq: b\n")
+}
+
+func TestRunFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file1, file2 := filepath.Join(dir, "1.json"), filepath.Join(dir, "2.json")
+	_ = ioutil.WriteFile(file1, []byte(`{"a": 1}`), 0644)
+	_ = ioutil.WriteFile(file2, []byte(`{"a": 2}`), 0644)
+
+	code, out, _ := runTest([]string{"a", file1, file2}, "")
+	xtesting.Equal(t, code, exitOk)
+	xtesting.Equal(t, out, "1\n2\n")
+}
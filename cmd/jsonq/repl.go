@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Aoi-hosizora/jsonq"
+	"github.com/peterh/liner"
+)
+
+const replHelp = `Type a selector to evaluate it, press Tab to complete keys, indexes and functions.
+Commands:
+  :help    show this help
+  :quit    exit (also Ctrl-D)
+`
+
+// Functions which could be used after "|" in selector.
+var replFuncs = []string{"avg", "count", "distinct", "first", "last", "max", "min", "sum"}
+
+// A line reader with history, it is implemented by liner.State.
+type prompter interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(item string)
+}
+
+// Run the interactive mode on a document, and return the exit code.
+func runRepl(doc *jsonq.JsonDocument, opts *options, stdout, stderr io.Writer) int {
+	state := liner.NewLiner()
+	defer state.Close()
+	state.SetCtrlCAborts(true)
+	state.SetCompleter(newCompleter(doc))
+
+	historyFile := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyFile = filepath.Join(home, ".jsonq_history")
+		if f, err := os.Open(historyFile); err == nil {
+			_, _ = state.ReadHistory(f)
+			_ = f.Close()
+		}
+	}
+
+	repl(state, doc, opts, stdout)
+
+	if historyFile != "" {
+		if f, err := os.Create(historyFile); err == nil {
+			_, _ = state.WriteHistory(f)
+			_ = f.Close()
+		} else {
+			printError(stderr, "jsonq: could not save history: ", err)
+		}
+	}
+	return exitOk
+}
+
+// Read selectors and print their results until exit.
+func repl(p prompter, doc *jsonq.JsonDocument, opts *options, stdout io.Writer) {
+	jq := jsonq.NewJsonQuery(doc)
+	for {
+		line, err := p.Prompt("jsonq> ")
+		if err != nil { // io.EOF or liner.ErrPromptAborted
+			return
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		p.AppendHistory(line)
+
+		switch line {
+		case ":q", ":quit", ":exit":
+			return
+		case ":h", ":help":
+			_, _ = fmt.Fprint(stdout, replHelp)
+			continue
+		}
+
+		res, err := jq.SelectBySelector(line)
+		if err != nil {
+			printError(stdout, "error: ", err)
+			continue
+		}
+		if err := output(stdout, res, opts); err != nil {
+			printError(stdout, "error: ", err)
+		}
+	}
+}
+
+// Create a completer which completes the field names and array indexes at the current layer, or function names after "|".
+func newCompleter(doc *jsonq.JsonDocument) func(line string) []string {
+	jq := jsonq.NewJsonQuery(doc)
+	return func(line string) []string {
+		layerStart, partStart := splitPartial(line)
+		before := strings.TrimSpace(line[:layerStart])
+		partial := line[partStart:]
+
+		var candidates []string
+		if before[strings.LastIndexAny(before, " \t\n")+1:] == "|" { // a pipe is separated by whitespaces
+			candidates = replFuncs
+		} else {
+			selector, err := jsonq.Compile(before)
+			if err != nil {
+				return nil
+			}
+			res, err := jq.SelectCompiled(selector)
+			if err != nil {
+				return nil
+			}
+			candidates = layerCandidates(res, selector.Multi())
+		}
+
+		out := make([]string, 0)
+		for _, c := range candidates {
+			if strings.HasPrefix(c, partial) {
+				out = append(out, line[:partStart]+c)
+			}
+		}
+		return out
+	}
+}
+
+// Get the start index of the current layer, and the start index of the current field (after "+").
+func splitPartial(line string) (layerStart int, partStart int) {
+	escaped := false
+	for idx, ch := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			layerStart, partStart = idx+1, idx+1
+		case ch == '+':
+			partStart = idx + 1
+		}
+	}
+	return layerStart, partStart
+}
+
+// Get the escaped field names and array indexes of the value, or of all values if multi is true.
+func layerCandidates(val interface{}, multi bool) []string {
+	vals := []interface{}{val}
+	if arr, ok := val.([]interface{}); ok && multi {
+		vals = arr
+	}
+
+	set := make(map[string]bool)
+	for _, v := range vals {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k := range v {
+				if k != "" {
+					set[jsonq.Escape(k)] = true
+				}
+			}
+		case []interface{}:
+			for idx := range v {
+				set[fmt.Sprintf("#%d", idx)] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if strings.HasPrefix(out[i], "#") && strings.HasPrefix(out[j], "#") { // array indexes
+			return len(out[i]) < len(out[j]) || (len(out[i]) == len(out[j]) && out[i] < out[j])
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"io"
+	"testing"
+)
+
+type fakePrompter struct {
+	lines   []string
+	history []string
+}
+
+func (f *fakePrompter) Prompt(string) (string, error) {
+	if len(f.lines) == 0 {
+		return "", io.EOF
+	}
+	line := f.lines[0]
+	f.lines = f.lines[1:]
+	return line, nil
+}
+
+func (f *fakePrompter) AppendHistory(item string) {
+	f.history = append(f.history, item)
+}
+
+func TestRepl(t *testing.T) {
+	doc, _ := jsonq.NewJsonDocument([]byte(testDoc))
+	p := &fakePrompter{lines: []string{"a", "", "c f * g | sum", "c x", ":quit", "a"}}
+	out := &bytes.Buffer{}
+	repl(p, doc, &options{compact: true}, out)
+
+	xtesting.Equal(t, out.String(), "\"b<c>\"\n579\nerror: Object map[f:[map[g:123 i:abc] map[g:456 i:def]]] does not contain field \"x\"\n")
+	xtesting.Equal(t, p.history, []string{"a", "c f * g | sum", "c x", ":quit"})
+}
+
+func TestCompleter(t *testing.T) {
+	doc, _ := jsonq.NewJsonDocument([]byte(`{"a b": 1, "#x": 2, "ab": {"c": [0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10]}, "d": [{"e": 1}, {"f": 2}]}`))
+	complete := newCompleter(doc)
+
+	xtesting.Equal(t, complete(""), []string{"\\#x", "a\\ b", "ab", "d"})
+	xtesting.Equal(t, complete("a"), []string{"a\\ b", "ab"})
+	xtesting.Equal(t, complete("a\\ "), []string{"a\\ b"})
+	xtesting.Equal(t, complete("ab "), []string{"ab c"})
+	xtesting.Equal(t, complete("ab c #1"), []string{"ab c #1", "ab c #10"})
+	xtesting.Equal(t, complete("ab c "), []string{"ab c #0", "ab c #1", "ab c #2", "ab c #3", "ab c #4", "ab c #5", "ab c #6", "ab c #7", "ab c #8", "ab c #9", "ab c #10"})
+	xtesting.Equal(t, complete("d * "), []string{"d * e", "d * f"})
+	xtesting.Equal(t, complete("d #0 e+"), []string{"d #0 e+e"})
+	xtesting.Equal(t, complete("ab c | s"), []string{"ab c | sum"})
+	xtesting.Equal(t, complete("ab c | m"), []string{"ab c | max", "ab c | min"})
+	xtesting.Equal(t, complete("ab c|"), []string{})
+	xtesting.Equal(t, len(complete("x ")), 0)
+	xtesting.Equal(t, complete("a\\ b "), []string{})
+
+	// the multiplicity is got from the tokens
+	doc, _ = jsonq.NewJsonDocument([]byte(`{"a*b": [{"x": 1}, {"y": 2}], "c": [{"x": 1}, {"y": 2}]}`))
+	complete = newCompleter(doc)
+	xtesting.Equal(t, complete("a*b "), []string{"a*b #0", "a*b #1"})
+	xtesting.Equal(t, complete("c #0: "), []string{"c #0: x", "c #0: y"})
+	xtesting.Equal(t, complete("~/^c$/ "), []string{"~/^c$/ #0", "~/^c$/ #1"})
+	xtesting.Equal(t, complete("c * | distinct "), []string{"c * | distinct #0", "c * | distinct #1"})
+}
@@ -0,0 +1,291 @@
+package jsonq
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// A sort key used in SortBy and SortByBySelector, which is selected relative to each element by the tokens, or by
+// the selector string if the tokens are nil. A key with no tokens and an empty selector string means the element itself.
+type SortKey struct {
+	Tokens   []interface{}
+	Selector string
+	Desc     bool
+}
+
+// Build an ascending sort key by tokens.
+func Asc(tokens ...interface{}) *SortKey {
+	return &SortKey{Tokens: tokens}
+}
+
+// Build a descending sort key by tokens.
+func Desc(tokens ...interface{}) *SortKey {
+	return &SortKey{Tokens: tokens, Desc: true}
+}
+
+// Build an ascending sort key by a selector string.
+func AscBySelector(selectorString string) *SortKey {
+	return &SortKey{Selector: selectorString}
+}
+
+// Build a descending sort key by a selector string.
+func DescBySelector(selectorString string) *SortKey {
+	return &SortKey{Selector: selectorString, Desc: true}
+}
+
+// Get the tokens of the sort key, the selector string is parsed if the tokens are nil.
+func (k *SortKey) tokens() ([]interface{}, error) {
+	if k.Tokens != nil {
+		return k.Tokens, nil
+	}
+	return _NewParser(k.Selector).Parse()
+}
+
+// Sort the array selected by the tokens, and return a new JsonQuery rooted at the sorted array. The sort is stable,
+// and elements are ordered by keys in turn. If no key is given, elements are ordered by themselves.
+//
+// Values are ordered by: null < false < true < numbers < strings < arrays < objects.
+//
+// In SortBy, GroupBy and DistinctBy, a key which could not be found in an element is treated as null, and the other
+// errors of selecting a key (such as ErrTypeMismatch) are returned.
+func (j *JsonQuery) SortBy(keys []*SortKey, tokens ...interface{}) (*JsonQuery, error) {
+	arr, err := j.selectArray(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		keys = []*SortKey{Asc()}
+	}
+	keyTokens := make([][]interface{}, len(keys))
+	for idx, key := range keys {
+		keyTokens[idx], err = key.tokens()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	type item struct {
+		val  interface{}
+		keys []interface{}
+	}
+	items := make([]*item, len(arr))
+	for idx, val := range arr {
+		items[idx] = &item{val: val, keys: make([]interface{}, len(keys))}
+		for kdx, tokens := range keyTokens {
+			items[idx].keys[kdx], err = j.selectKey(val, tokens)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.SliceStable(items, func(i, k int) bool {
+		for idx, key := range keys {
+			c := compareValues(items[i].keys[idx], items[k].keys[idx])
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != key.Desc
+		}
+		return false
+	})
+
+	out := make([]interface{}, len(items))
+	for idx, it := range items {
+		out[idx] = it.val
+	}
+	return j.withBlob(out), nil
+}
+
+// Sort the array selected by a selector string (an empty string means the root), see SortBy.
+func (j *JsonQuery) SortByBySelector(selectorString string, keys ...*SortKey) (*JsonQuery, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.SortBy(keys, selector...)
+}
+
+// Group the elements of the array selected by the tokens by the key tokens selected relative to each element, and
+// return a new JsonQuery rooted at an object which maps the keys to the arrays of elements. String keys are used as
+// they are, and other keys are converted to their canonical json form, such as "1", "true" and "null". The string keys
+// could not be mixed with other keys, because they may collide (such as "1" and 1). A key which could not be found is
+// treated as null, see SortBy.
+func (j *JsonQuery) GroupBy(keyTokens []interface{}, tokens ...interface{}) (*JsonQuery, error) {
+	arr, err := j.selectArray(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+	var first interface{} // the first key, to check if the keys are mixed
+	for idx, val := range arr {
+		key, err := j.selectKey(val, keyTokens)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if idx == 0 {
+			first = key
+		} else if _, firstOk := first.(string); ok != firstOk {
+			return nil, kindErrorf(ErrTypeMismatch, "Could not group by mixed string and non-string keys %v and %v\n", first, key)
+		}
+		if !ok {
+			bs, err := Canonicalize(key)
+			if err != nil {
+				return nil, err
+			}
+			name = string(bs)
+		}
+		group, _ := out[name].([]interface{})
+		out[name] = append(group, val)
+	}
+	return j.withBlob(out), nil
+}
+
+// Group the elements of the array selected by a selector string (an empty string means the root) by a key selector
+// string, see GroupBy.
+func (j *JsonQuery) GroupByBySelector(selectorString string, keySelector string) (*JsonQuery, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	keyTokens, err := _NewParser(keySelector).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.GroupBy(keyTokens, selector...)
+}
+
+// Remove the elements which have the same key from the array selected by the tokens, and return a new JsonQuery
+// rooted at the new array. The key tokens are selected relative to each element, and only the first element of each
+// key is kept. A key which could not be found is treated as null, see SortBy.
+func (j *JsonQuery) DistinctBy(keyTokens []interface{}, tokens ...interface{}) (*JsonQuery, error) {
+	arr, err := j.selectArray(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, val := range arr {
+		key, err := j.selectKey(val, keyTokens)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := Hash(key)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[hash] {
+			seen[hash] = true
+			out = append(out, val)
+		}
+	}
+	return j.withBlob(out), nil
+}
+
+// Remove the elements which have the same key from the array selected by a selector string (an empty string means
+// the root) by a key selector string, see DistinctBy.
+func (j *JsonQuery) DistinctByBySelector(selectorString string, keySelector string) (*JsonQuery, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	keyTokens, err := _NewParser(keySelector).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.DistinctBy(keyTokens, selector...)
+}
+
+// Select an array by the tokens, the results of multiToken and starToken are also treated as an array.
+func (j *JsonQuery) selectArray(tokens []interface{}) ([]interface{}, error) {
+	vals, multi, err := j.rquery(j.blobFor(tokens), tokens...)
+	if err != nil {
+		return nil, err
+	}
+	if multi {
+		return vals, nil
+	}
+	return interfaceToArray(vals[0])
+}
+
+// Select a key relative to an element, returns nil if the key could not be found.
+func (j *JsonQuery) selectKey(blob interface{}, tokens []interface{}) (interface{}, error) {
+	vals, multi, err := j.rquery(blob, tokens...)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if multi {
+		return vals, nil
+	}
+	return vals[0], nil
+}
+
+// Get the rank of a json value's type, used to order values of different types.
+func valueRank(i interface{}) int {
+	if _, ok := numberToFloat64(i); ok {
+		return 2
+	}
+	switch i.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	}
+	return 5
+}
+
+// Compare two json values, returns -1 if a < b, 0 if a == b, and 1 if a > b.
+func compareValues(a, b interface{}) int {
+	ra, rb := valueRank(a), valueRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+
+	switch ra {
+	case 1:
+		ba, bb := a.(bool), b.(bool)
+		if ba == bb {
+			return 0
+		} else if !ba {
+			return -1
+		}
+		return 1
+	case 2:
+		fa, _ := numberToFloat64(a)
+		fb, _ := numberToFloat64(b)
+		if fa < fb {
+			return -1
+		} else if fa > fb {
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(a.(string), b.(string))
+	case 4:
+		aa, ab := a.([]interface{}), b.([]interface{})
+		for idx := 0; idx < len(aa) && idx < len(ab); idx++ {
+			if c := compareValues(aa[idx], ab[idx]); c != 0 {
+				return c
+			}
+		}
+		return compareValues(float64(len(aa)), float64(len(ab)))
+	case 5:
+		ca, _ := Canonicalize(a)
+		cb, _ := Canonicalize(b)
+		return bytes.Compare(ca, cb)
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+var listDoc = `
+{
+	"users": [
+		{"name": "alice", "age": 30, "team": {"id": 2}},
+		{"name": "bob", "age": 25, "team": {"id": 1}},
+		{"name": "carol", "age": 30, "team": {"id": 1}},
+		{"name": "dave", "team": {"id": 2}},
+		{"name": "eve", "age": 25, "team": {"id": 3}}
+	]
+}
+`
+
+func TestCollection(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(listDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	sorted1 := handle(jq.SortBy([]*SortKey{Asc("age")}, "users")).(*JsonQuery)
+	xtesting.Equal(t, handle(sorted1.Strings(All(), "name")), []string{"dave", "bob", "eve", "alice", "carol"})
+	sorted2 := handle(jq.SortByBySelector("users", DescBySelector("age"), Asc("team", "id"))).(*JsonQuery)
+	xtesting.Equal(t, handle(sorted2.StringsBySelector("* name")), []string{"carol", "alice", "bob", "eve", "dave"})
+	sorted3 := handle(jq.SortByBySelector("users * team id")).(*JsonQuery)
+	xtesting.Equal(t, handle(sorted3.Int64s()), []int64{1, 1, 2, 2, 3})
+	sorted4 := handle(jq.SortBy([]*SortKey{DescBySelector("team id"), Asc("name")}, "users")).(*JsonQuery)
+	xtesting.Equal(t, handle(sorted4.Strings(All(), "name")), []string{"eve", "alice", "dave", "bob", "carol"})
+
+	grouped1 := handle(jq.GroupBy([]interface{}{"team", "id"}, "users")).(*JsonQuery)
+	xtesting.Equal(t, handle(grouped1.Strings("1", All(), "name")), []string{"bob", "carol"})
+	xtesting.Equal(t, handle(grouped1.CountBySelector("2")), 2)
+	xtesting.Equal(t, handle(grouped1.Count()), 3)
+	grouped2 := handle(jq.GroupByBySelector("users * team", "id")).(*JsonQuery)
+	xtesting.Equal(t, handle(grouped2.Keys()), []string{"1", "2", "3"})
+	grouped3 := handle(jq.GroupByBySelector("users", "name")).(*JsonQuery)
+	xtesting.Equal(t, handle(grouped3.Keys()), []string{"alice", "bob", "carol", "dave", "eve"})
+	grouped4 := handle(jq.GroupByBySelector("users", "age")).(*JsonQuery) // dave has no age
+	xtesting.Equal(t, handle(grouped4.Keys()), []string{"25", "30", "null"})
+	xtesting.Equal(t, handle(grouped4.StringsBySelector("null * name")), []string{"dave"})
+
+	distinct1 := handle(jq.DistinctByBySelector("users", "age")).(*JsonQuery)
+	xtesting.Equal(t, handle(distinct1.StringsBySelector("* name")), []string{"alice", "bob", "dave"})
+	distinct2 := handle(jq.DistinctBy([]interface{}{"team", "id"}, "users")).(*JsonQuery)
+	xtesting.Equal(t, handle(distinct2.Strings(All(), "name")), []string{"alice", "bob", "eve"})
+
+	// errors
+	mixed, err := NewJsonDocument([]byte(`[1, "1", null, true, {"a": 1}]`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	_, err = NewJsonQuery(mixed).GroupBy(nil)
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = NewJsonQuery(mixed).GroupByBySelector("#2:4", "")
+	xtesting.Equal(t, err, nil)
+	_, err = NewJsonQuery(mixed).SortBy([]*SortKey{Asc(0)})
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = NewJsonQuery(mixed).DistinctBy([]interface{}{0})
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = jq.SortByBySelector("users #0", AscBySelector("age"))
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = jq.SortByBySelector("users", AscBySelector("age |"))
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = jq.GroupByBySelector("users", "team |")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = jq.DistinctByBySelector("x", "age")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	xtesting.Equal(t, compareValues(nil, false), -1)
+	xtesting.Equal(t, compareValues(true, 1.), -1)
+	xtesting.Equal(t, compareValues("1", 1.), 1)
+	xtesting.Equal(t, compareValues([]interface{}{1., 2.}, []interface{}{1.}), 1)
+	xtesting.Equal(t, compareValues(map[string]interface{}{"a": 1.}, map[string]interface{}{"a": 1.}), 0)
+}
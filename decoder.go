@@ -0,0 +1,629 @@
+package jsonq
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Syntax of the input of a JsonDocument, used in ParseOptions.
+type Syntax int
+
+const (
+	// Standard json (RFC 8259).
+	SyntaxJson Syntax = iota
+
+	// Json with comments (JSONC), which allows "//" and "/* */" comments and trailing commas.
+	SyntaxJsonc
+
+	// JSON5, which allows comments, trailing commas, unquoted keys, single-quoted strings, hexadecimal numbers, leading
+	// and trailing decimal points, explicit plus signs, Infinity and NaN.
+	SyntaxJson5
+)
+
+// Policy of handling duplicated object keys, used in ParseOptions.
+type DuplicateKeyPolicy int
+
+const (
+	// Keep the value of the last duplicated key, which is the same as encoding/json.
+	KeepLastDuplicate DuplicateKeyPolicy = iota
+
+	// Reject the input with a *DuplicateKeyError.
+	RejectDuplicates
+
+	// Keep the value of the first duplicated key.
+	KeepFirstDuplicate
+
+	// Collect the values of a duplicated key into an array in the order of input, the keys which are not duplicated are
+	// not affected.
+	CollectDuplicates
+)
+
+// A hand-written json decoder, which decodes the input to the same blob as encoding/json, and supports more syntaxes.
+type _Decoder struct {
+	data    []byte
+	pos     int
+	depth   int
+	options ParseOptions
+
+	// for RetainSpans, the span blob of the last decoded value, and the ranges of the containers in span blobs
+	lastSpan   interface{}
+	containers map[uintptr]spanRange
+}
+
+func _NewDecoder(data []byte, options ParseOptions) *_Decoder {
+	d := &_Decoder{data: data, options: options}
+	if options.RetainSpans {
+		d.containers = make(map[uintptr]spanRange)
+	}
+	return d
+}
+
+// Decode the input, which must be an object or an array.
+func (d *_Decoder) Decode() (interface{}, error) {
+	if max := d.options.MaxBytes; max > 0 && len(d.data) > max {
+		d.pos = max
+		return nil, d.limitError("bytes", max)
+	}
+	if err := d.skipSpaces(); err != nil {
+		return nil, err
+	}
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("Expected json string, got an empty string")
+	}
+	if ch := d.data[d.pos]; ch != '{' && ch != '[' {
+		return nil, d.errorf("Expected [ or { as the json's first token, got \"%c\"", ch)
+	}
+	val, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.skipSpaces(); err != nil {
+		return nil, err
+	}
+	if d.pos < len(d.data) {
+		return nil, d.errorf("Invalid character '%c' after top-level value", d.data[d.pos])
+	}
+	return val, nil
+}
+
+// Create a *ParseError at the current position.
+func (d *_Decoder) errorf(format string, v ...interface{}) error {
+	return newParseError(d.data, d.pos, fmt.Sprintf(format, v...))
+}
+
+// Create a LimitError at the current position.
+func (d *_Decoder) limitError(limit string, max int) error {
+	line, column := position(d.data, d.pos)
+	return &LimitError{Limit: limit, Max: max, Offset: d.pos, Line: line, Column: column}
+}
+
+// Get the line and column (both start from 1, and the column counts characters) of the byte offset in data.
+func position(data []byte, offset int) (line int, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	return bytes.Count(data[:offset], []byte{'\n'}) + 1, utf8.RuneCount(data[lineStart:offset]) + 1
+}
+
+// Skip whitespaces, and comments for JSONC and JSON5.
+func (d *_Decoder) skipSpaces() error {
+	for d.pos < len(d.data) {
+		ch, size := d.data[d.pos], 1
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		case ch == '/' && d.options.Syntax != SyntaxJson && d.pos+1 < len(d.data) && d.data[d.pos+1] == '/':
+			end := bytes.IndexByte(d.data[d.pos:], '\n')
+			if end == -1 {
+				end = len(d.data) - d.pos
+			}
+			size = end
+		case ch == '/' && d.options.Syntax != SyntaxJson && d.pos+1 < len(d.data) && d.data[d.pos+1] == '*':
+			end := bytes.Index(d.data[d.pos+2:], []byte("*/"))
+			if end == -1 {
+				return d.errorf("Unterminated block comment")
+			}
+			size = end + 4
+		case d.options.Syntax == SyntaxJson5 && ch >= utf8.RuneSelf:
+			r, rs := utf8.DecodeRune(d.data[d.pos:])
+			if !unicode.IsSpace(r) && r != '\uFEFF' {
+				return nil
+			}
+			size = rs
+		case d.options.Syntax == SyntaxJson5 && (ch == '\v' || ch == '\f'):
+		default:
+			return nil
+		}
+		d.pos += size
+	}
+	return nil
+}
+
+// Decode a value at the current position, and record its span if RetainSpans is set.
+func (d *_Decoder) value() (interface{}, error) {
+	start := d.pos
+	val, err := d.rawValue()
+	if err != nil || !d.options.RetainSpans {
+		return val, err
+	}
+	switch val.(type) {
+	case map[string]interface{}, []interface{}: // lastSpan is set by object or array
+		d.containers[reflect.ValueOf(d.lastSpan).Pointer()] = spanRange{start: start, end: d.pos}
+	default:
+		d.lastSpan = spanRange{start: start, end: d.pos}
+	}
+	return val, nil
+}
+
+// Decode a value at the current position without recording its span.
+func (d *_Decoder) rawValue() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("Unexpected end of json input")
+	}
+	switch ch := d.data[d.pos]; {
+	case ch == '{' || ch == '[':
+		d.depth++
+		if max := d.options.MaxDepth; max > 0 && d.depth > max {
+			return nil, d.limitError("depth", max)
+		}
+		var val interface{}
+		var err error
+		if ch == '{' {
+			val, err = d.object()
+		} else {
+			val, err = d.array()
+		}
+		d.depth--
+		return val, err
+	case ch == '"' || (ch == '\'' && d.options.Syntax == SyntaxJson5):
+		return d.string()
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		return d.number()
+	}
+	for _, lit := range []struct {
+		word  string
+		value interface{}
+		json5 bool
+	}{
+		{"true", true, false}, {"false", false, false}, {"null", nil, false},
+		{"Infinity", math.Inf(1), true}, {"NaN", math.NaN(), true},
+	} {
+		if bytes.HasPrefix(d.data[d.pos:], []byte(lit.word)) && (!lit.json5 || d.options.Syntax == SyntaxJson5) {
+			d.pos += len(lit.word)
+			return lit.value, nil
+		}
+	}
+	return nil, d.errorf("Invalid character '%c' looking for beginning of value", d.data[d.pos])
+}
+
+// Decode an object at the current position.
+func (d *_Decoder) object() (interface{}, error) {
+	obj := make(map[string]interface{})
+	count := 0                    // count of fields, including the duplicated ones
+	var collected map[string]bool // keys whose values are collected, for CollectDuplicates
+	if d.options.DuplicateKeys == CollectDuplicates {
+		collected = make(map[string]bool)
+	}
+	var spanObj map[string]interface{} // for RetainSpans, the fields are handled the same as obj
+	var spanCollected map[string]bool
+	if d.options.RetainSpans {
+		spanObj, spanCollected = make(map[string]interface{}), make(map[string]bool)
+		defer func() { d.lastSpan = spanObj }()
+	}
+	d.pos++ // {
+	for {
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		if d.pos < len(d.data) && d.data[d.pos] == '}' && (len(obj) == 0 || d.options.Syntax != SyntaxJson) {
+			d.pos++
+			return obj, nil
+		}
+
+		keyStart := d.pos
+		if count++; d.options.MaxElements > 0 && count > d.options.MaxElements {
+			return nil, d.limitError("elements", d.options.MaxElements)
+		}
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		if d.pos >= len(d.data) || d.data[d.pos] != ':' {
+			return nil, d.expected("':' after object key")
+		}
+		d.pos++
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		val, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.setField(obj, collected, key, val, keyStart); err != nil {
+			return nil, err
+		}
+		if spanObj != nil {
+			_ = d.setField(spanObj, spanCollected, key, d.lastSpan, keyStart)
+		}
+
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		if d.pos < len(d.data) && d.data[d.pos] == '}' {
+			d.pos++
+			return obj, nil
+		}
+		if d.pos >= len(d.data) || d.data[d.pos] != ',' {
+			return nil, d.expected("',' or '}' after object value")
+		}
+		d.pos++
+		if d.options.Syntax == SyntaxJson {
+			if err := d.skipSpaces(); err != nil {
+				return nil, err
+			}
+			if d.pos < len(d.data) && d.data[d.pos] == '}' {
+				return nil, d.errorf("Invalid trailing comma in object")
+			}
+		}
+	}
+}
+
+// Set a field of the object, the duplicated keys are handled by the DuplicateKeyPolicy.
+func (d *_Decoder) setField(obj map[string]interface{}, collected map[string]bool, key string, val interface{}, keyStart int) error {
+	old, ok := obj[key]
+	if !ok {
+		obj[key] = val
+		return nil
+	}
+	switch d.options.DuplicateKeys {
+	case RejectDuplicates:
+		line, column := position(d.data, keyStart)
+		return &DuplicateKeyError{Key: key, Offset: keyStart, Line: line, Column: column}
+	case KeepFirstDuplicate:
+	case CollectDuplicates:
+		if collected[key] {
+			obj[key] = append(old.([]interface{}), val)
+		} else {
+			obj[key] = []interface{}{old, val}
+			collected[key] = true
+		}
+	default:
+		obj[key] = val
+	}
+	return nil
+}
+
+// Decode an object key at the current position, which is an identifier or a string in JSON5.
+func (d *_Decoder) key() (string, error) {
+	if d.pos >= len(d.data) {
+		return "", d.errorf("Unexpected end of json input")
+	}
+	if ch := d.data[d.pos]; ch == '"' || (ch == '\'' && d.options.Syntax == SyntaxJson5) {
+		return d.string()
+	}
+	if d.options.Syntax != SyntaxJson5 {
+		return "", d.expected("string for object key")
+	}
+
+	start := d.pos
+	for d.pos < len(d.data) {
+		r, size := utf8.DecodeRune(d.data[d.pos:])
+		if !isIdentRune(r, d.pos == start) {
+			break
+		}
+		d.pos += size
+	}
+	if d.pos == start {
+		return "", d.expected("identifier or string for object key")
+	}
+	if max := d.options.MaxStringLength; max > 0 && d.pos-start > max {
+		d.pos = start + max
+		return "", d.limitError("string length", max)
+	}
+	return string(d.data[start:d.pos]), nil
+}
+
+// Check if the rune could be in an unquoted key in JSON5 (ECMAScript IdentifierName, without unicode escapes).
+func isIdentRune(r rune, first bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) {
+		return true
+	}
+	return !first && (unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D')
+}
+
+// Decode an array at the current position.
+func (d *_Decoder) array() (interface{}, error) {
+	arr := make([]interface{}, 0)
+	var spanArr []interface{} // for RetainSpans
+	if d.options.RetainSpans {
+		spanArr = make([]interface{}, 0, 1) // not empty, so that its pointer is unique
+		defer func() { d.lastSpan = spanArr }()
+	}
+	d.pos++ // [
+	for {
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		if d.pos < len(d.data) && d.data[d.pos] == ']' && (len(arr) == 0 || d.options.Syntax != SyntaxJson) {
+			d.pos++
+			return arr, nil
+		}
+
+		if max := d.options.MaxElements; max > 0 && len(arr) == max {
+			return nil, d.limitError("elements", max)
+		}
+		val, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+		if spanArr != nil {
+			spanArr = append(spanArr, d.lastSpan)
+		}
+
+		if err := d.skipSpaces(); err != nil {
+			return nil, err
+		}
+		if d.pos < len(d.data) && d.data[d.pos] == ']' {
+			d.pos++
+			return arr, nil
+		}
+		if d.pos >= len(d.data) || d.data[d.pos] != ',' {
+			return nil, d.expected("',' or ']' after array element")
+		}
+		d.pos++
+		if d.options.Syntax == SyntaxJson {
+			if err := d.skipSpaces(); err != nil {
+				return nil, err
+			}
+			if d.pos < len(d.data) && d.data[d.pos] == ']' {
+				return nil, d.errorf("Invalid trailing comma in array")
+			}
+		}
+	}
+}
+
+// Create an error for an unexpected character or the end of input.
+func (d *_Decoder) expected(what string) error {
+	if d.pos >= len(d.data) {
+		return d.errorf("Unexpected end of json input")
+	}
+	return d.errorf("Invalid character '%c', expected %s", d.data[d.pos], what)
+}
+
+// Decode a string at the current position, the quote could be " or ' (JSON5).
+func (d *_Decoder) string() (string, error) {
+	quote := d.data[d.pos]
+	d.pos++
+	buf := make([]byte, 0, 16)
+	last := d.pos // start of the last character
+	for {
+		if max := d.options.MaxStringLength; max > 0 && len(buf) > max {
+			d.pos = last
+			return "", d.limitError("string length", max)
+		}
+		last = d.pos
+		if d.pos >= len(d.data) {
+			return "", d.errorf("Unexpected end of json input")
+		}
+		ch := d.data[d.pos]
+		switch {
+		case ch == quote:
+			d.pos++
+			return string(buf), nil
+		case ch == '\\':
+			d.pos++
+			if err := d.escape(&buf); err != nil {
+				return "", err
+			}
+		case ch < 0x20:
+			return "", d.errorf("Invalid control character in string")
+		case ch >= utf8.RuneSelf:
+			r, size := utf8.DecodeRune(d.data[d.pos:])
+			buf = append(buf, string(r)...) // invalid utf-8 is replaced by U+FFFD like encoding/json
+			d.pos += size
+		default:
+			buf = append(buf, ch)
+			d.pos++
+		}
+	}
+}
+
+// Decode an escape sequence after "\" in a string, and append it to the buffer.
+func (d *_Decoder) escape(buf *[]byte) error {
+	if d.pos >= len(d.data) {
+		return d.errorf("Unexpected end of json input")
+	}
+	ch := d.data[d.pos]
+	d.pos++
+	switch ch {
+	case '"', '\\', '/':
+		*buf = append(*buf, ch)
+	case 'b':
+		*buf = append(*buf, '\b')
+	case 'f':
+		*buf = append(*buf, '\f')
+	case 'n':
+		*buf = append(*buf, '\n')
+	case 'r':
+		*buf = append(*buf, '\r')
+	case 't':
+		*buf = append(*buf, '\t')
+	case 'u':
+		r, err := d.hexRune(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) && bytes.HasPrefix(d.data[d.pos:], []byte(`\u`)) {
+			pos := d.pos
+			d.pos += 2
+			r2, err := d.hexRune(4)
+			if err != nil {
+				return err
+			}
+			if dec := utf16.DecodeRune(r, r2); dec != unicode.ReplacementChar {
+				r = dec
+			} else {
+				d.pos = pos // not a pair, decode it alone
+			}
+		}
+		*buf = append(*buf, string(r)...) // unpaired surrogate is replaced by U+FFFD
+	default:
+		if d.options.Syntax != SyntaxJson5 {
+			d.pos--
+			return d.errorf("Invalid escape character '%c' in string", ch)
+		}
+		return d.escape5(buf, ch)
+	}
+	return nil
+}
+
+// Decode the escape sequences only allowed in JSON5.
+func (d *_Decoder) escape5(buf *[]byte, ch byte) error {
+	switch ch {
+	case '\'':
+		*buf = append(*buf, '\'')
+	case 'v':
+		*buf = append(*buf, '\v')
+	case '0':
+		if d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9' {
+			return d.errorf("Invalid digit after \\0 in string")
+		}
+		*buf = append(*buf, 0)
+	case 'x':
+		r, err := d.hexRune(2)
+		if err != nil {
+			return err
+		}
+		*buf = append(*buf, string(r)...)
+	case '\n': // line continuation
+	case '\r':
+		if d.pos < len(d.data) && d.data[d.pos] == '\n' {
+			d.pos++
+		}
+	default:
+		if ch >= '1' && ch <= '9' {
+			d.pos--
+			return d.errorf("Invalid escape character '%c' in string", ch)
+		}
+		d.pos-- // any other character represents itself, including U+2028 and U+2029 line continuations
+		r, size := utf8.DecodeRune(d.data[d.pos:])
+		d.pos += size
+		if r != '\u2028' && r != '\u2029' {
+			*buf = append(*buf, string(r)...)
+		}
+	}
+	return nil
+}
+
+// Decode n hexadecimal digits to a rune.
+func (d *_Decoder) hexRune(n int) (rune, error) {
+	if d.pos+n > len(d.data) {
+		return 0, d.errorf("Unexpected end of json input")
+	}
+	v, err := strconv.ParseUint(string(d.data[d.pos:d.pos+n]), 16, 32)
+	if err != nil {
+		return 0, d.errorf("Invalid hexadecimal escape in string")
+	}
+	d.pos += n
+	return rune(v), nil
+}
+
+// Decode a number at the current position to a float64.
+func (d *_Decoder) number() (interface{}, error) {
+	start := d.pos
+	neg := false
+	if ch := d.data[d.pos]; ch == '-' || (ch == '+' && d.options.Syntax == SyntaxJson5) {
+		neg = ch == '-'
+		d.pos++
+	}
+
+	if d.options.Syntax == SyntaxJson5 {
+		rest := d.data[d.pos:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("Infinity")):
+			d.pos += len("Infinity")
+			if neg {
+				return math.Inf(-1), nil
+			}
+			return math.Inf(1), nil
+		case bytes.HasPrefix(rest, []byte("NaN")):
+			d.pos += len("NaN")
+			return math.NaN(), nil
+		case bytes.HasPrefix(rest, []byte("0x")) || bytes.HasPrefix(rest, []byte("0X")):
+			d.pos += 2
+			digits := d.pos
+			for d.pos < len(d.data) && isHexDigit(d.data[d.pos]) {
+				d.pos++
+			}
+			v, err := strconv.ParseUint(string(d.data[digits:d.pos]), 16, 64)
+			if err != nil {
+				d.pos = start
+				return nil, d.errorf("Invalid hexadecimal number")
+			}
+			if neg {
+				return -float64(v), nil
+			}
+			return float64(v), nil
+		}
+	}
+
+	intDigits := d.digits()
+	if intDigits > 1 && d.data[d.pos-intDigits] == '0' {
+		d.pos = start
+		return nil, d.errorf("Invalid leading zero in number")
+	}
+	fracDigits, hasDot := 0, false
+	if d.pos < len(d.data) && d.data[d.pos] == '.' {
+		d.pos++
+		fracDigits, hasDot = d.digits(), true
+	}
+	valid := intDigits > 0 && (!hasDot || fracDigits > 0)
+	if d.options.Syntax == SyntaxJson5 { // .5 and 5.
+		valid = intDigits > 0 || fracDigits > 0
+	}
+	if !valid {
+		d.pos = start
+		return nil, d.errorf("Invalid number")
+	}
+	if d.pos < len(d.data) && (d.data[d.pos] == 'e' || d.data[d.pos] == 'E') {
+		d.pos++
+		if d.pos < len(d.data) && (d.data[d.pos] == '+' || d.data[d.pos] == '-') {
+			d.pos++
+		}
+		if d.digits() == 0 {
+			d.pos = start
+			return nil, d.errorf("Invalid exponent in number")
+		}
+	}
+
+	text := string(d.data[start:d.pos])
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		d.pos = start
+		return nil, d.errorf("Number %s is out of range", text)
+	}
+	return f, nil
+}
+
+// Skip the decimal digits, and return the count of them.
+func (d *_Decoder) digits() int {
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9' {
+		d.pos++
+	}
+	return d.pos - start
+}
+
+func isHexDigit(ch byte) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
@@ -0,0 +1,167 @@
+package jsonq
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	for _, input := range []string{objDoc, arrDoc, sepDoc, `[1e3, -0.5, 1E-2, "é😀\"\\\/\b\f\n\r\t", true, false, null, {}, []]`} {
+		var expected interface{}
+		_ = json.Unmarshal([]byte(input), &expected)
+		for _, syntax := range []Syntax{SyntaxJson, SyntaxJsonc, SyntaxJson5} {
+			val, err := _NewDecoder([]byte(input), ParseOptions{Syntax: syntax}).Decode()
+			xtesting.Equal(t, err, nil)
+			xtesting.Equal(t, val, expected)
+		}
+	}
+
+	for _, input := range []string{
+		"", "1", "{", `{"a"}`, `{"a": 1,}`, `[1, 2,]`, `[01]`, `[1.]`, `[.5]`, `[+1]`, `[0x10]`, `["a\x"]`, "[\"a\nb\"]",
+		`{a: 1}`, `['a']`, `[NaN]`, `[Infinity]`, `[1] 2`, `[1] // comment`, `[1e999]`, `[tru]`,
+	} {
+		_, err := _NewDecoder([]byte(input), ParseOptions{}).Decode()
+		xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+		xtesting.Equal(t, strings.Contains(err.Error(), "at line "), true)
+	}
+}
+
+func TestDecoderJsonc(t *testing.T) {
+	input := `// config
+{
+	/* the name
+	   of the service */
+	"name": "svc", // inline
+	"ports": [80, 443,],
+	"url": "http://a/*b*/",
+}
+`
+	doc, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Syntax: SyntaxJsonc})
+	xtesting.Equal(t, err, nil)
+	jq := NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.StringBySelector("name")), "svc")
+	xtesting.Equal(t, handle(jq.SelectBySelector("ports")), []interface{}{80., 443.})
+	xtesting.Equal(t, handle(jq.StringBySelector("url")), "http://a/*b*/")
+
+	for _, input := range []string{`{a: 1}`, `['a']`, `[0x1]`, `{"a": 1 /* unterminated`} {
+		_, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Syntax: SyntaxJsonc})
+		xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	}
+
+	_, err = NewJsonDocumentWithOptions([]byte("{\n\t\"a\": 1,\n\t\"b\": [1 2]\n}"), ParseOptions{Syntax: SyntaxJsonc})
+	xtesting.Equal(t, err.Error(), "Invalid character '2', expected ',' or ']' after array element at line 3, column 10\n")
+	_, err = NewJsonDocumentWithOptions([]byte("{\n\t\"é\": ?}"), ParseOptions{Syntax: SyntaxJsonc})
+	xtesting.Equal(t, err.Error(), "Invalid character '?' looking for beginning of value at line 2, column 7\n")
+}
+
+func TestDecoderJson5(t *testing.T) {
+	input := `// JSON5 example
+{
+	unquoted: 'and you can quote me on that',
+	singleQuotes: 'I can use "double quotes" here',
+	lineBreaks: "Look, Mom! \
+No \\n's!",
+	hexadecimal: 0xdecaf,
+	leadingDecimalPoint: .8675309, andTrailing: 8675309.,
+	positiveSign: +1,
+	negativeHex: -0x10,
+	trailingComma: 'in objects', andIn: ['arrays',],
+	"backwardsCompatible": "with JSON",
+	$_ident2: [Infinity, -Infinity, NaN],
+	escapes: '\x41\'\v\0',
+}`
+	doc, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Syntax: SyntaxJson5})
+	xtesting.Equal(t, err, nil)
+	jq := NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.StringBySelector("unquoted")), "and you can quote me on that")
+	xtesting.Equal(t, handle(jq.StringBySelector("singleQuotes")), `I can use "double quotes" here`)
+	xtesting.Equal(t, handle(jq.StringBySelector("lineBreaks")), `Look, Mom! No \n's!`)
+	xtesting.Equal(t, handle(jq.Int64BySelector("hexadecimal")), int64(0xdecaf))
+	xtesting.Equal(t, handle(jq.Float64BySelector("leadingDecimalPoint")), .8675309)
+	xtesting.Equal(t, handle(jq.Float64BySelector("andTrailing")), 8675309.)
+	xtesting.Equal(t, handle(jq.Float64BySelector("positiveSign")), 1.)
+	xtesting.Equal(t, handle(jq.Float64BySelector("negativeHex")), -16.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("andIn")), []interface{}{"arrays"})
+	xtesting.Equal(t, handle(jq.StringBySelector("backwardsCompatible")), "with JSON")
+	xtesting.Equal(t, handle(jq.Float64BySelector("$_ident2 #0")), math.Inf(1))
+	xtesting.Equal(t, handle(jq.Float64BySelector("$_ident2 #1")), math.Inf(-1))
+	xtesting.Equal(t, math.IsNaN(handle(jq.Float64BySelector("$_ident2 #2")).(float64)), true)
+	xtesting.Equal(t, handle(jq.StringBySelector("escapes")), "A'\v\x00")
+
+	for _, input := range []string{`{1a: 1}`, `[0x]`, `['a\1']`, `[01]`, `[.]`, `['a`, `{a 1}`} {
+		_, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Syntax: SyntaxJson5})
+		xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	input := `{
+	"a": 1,
+	"b": {"c": [1], "c": [2], "c": [3]},
+	"a": 2
+}`
+	for _, tc := range []struct {
+		policy DuplicateKeyPolicy
+		a      interface{}
+		c      interface{}
+	}{
+		{KeepLastDuplicate, 2., []interface{}{3.}},
+		{KeepFirstDuplicate, 1., []interface{}{1.}},
+		{CollectDuplicates, []interface{}{1., 2.}, []interface{}{[]interface{}{1.}, []interface{}{2.}, []interface{}{3.}}},
+	} {
+		for _, syntax := range []Syntax{SyntaxJson, SyntaxJson5} {
+			doc, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Syntax: syntax, DuplicateKeys: tc.policy})
+			xtesting.Equal(t, err, nil)
+			jq := NewJsonQuery(doc)
+			xtesting.Equal(t, handle(jq.Select("a")), tc.a)
+			xtesting.Equal(t, handle(jq.Select("b", "c")), tc.c)
+		}
+	}
+
+	_, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{DuplicateKeys: RejectDuplicates})
+	dupErr, ok := err.(*DuplicateKeyError)
+	xtesting.Equal(t, ok, true)
+	xtesting.Equal(t, *dupErr, DuplicateKeyError{Key: "c", Offset: 28, Line: 3, Column: 18})
+	xtesting.Equal(t, err.Error(), "Duplicate key \"c\" at offset 28 (line 3, column 18)\n")
+	_, err = NewJsonDocumentWithOptions([]byte(`[{"a": 1}, {"a": 1, "b": 2}]`), ParseOptions{DuplicateKeys: RejectDuplicates})
+	xtesting.Equal(t, err, nil)
+}
+
+func TestParseLimits(t *testing.T) {
+	for _, tc := range []struct {
+		input   string
+		options ParseOptions
+		err     *LimitError
+	}{
+		{`[[1], {"a": {}}]`, ParseOptions{MaxDepth: 3}, nil},
+		{`[[1], {"a": {}}]`, ParseOptions{MaxDepth: 2}, &LimitError{Limit: "depth", Max: 2, Offset: 12, Line: 1, Column: 13}},
+		{"[\n[[[", ParseOptions{MaxDepth: 2}, &LimitError{Limit: "depth", Max: 2, Offset: 3, Line: 2, Column: 2}},
+		{`[1, 2, 3]`, ParseOptions{MaxBytes: 9}, nil},
+		{`[1, 2, 3] `, ParseOptions{MaxBytes: 9}, &LimitError{Limit: "bytes", Max: 9, Offset: 9, Line: 1, Column: 10}},
+		{`{"abc": "abcd"}`, ParseOptions{MaxStringLength: 4}, nil},
+		{`{"abc": "abcde"}`, ParseOptions{MaxStringLength: 4}, &LimitError{Limit: "string length", Max: 4, Offset: 13, Line: 1, Column: 14}},
+		{`{"abcde": 1}`, ParseOptions{MaxStringLength: 4}, &LimitError{Limit: "string length", Max: 4, Offset: 6, Line: 1, Column: 7}},
+		{`{abcde: 1}`, ParseOptions{MaxStringLength: 4, Syntax: SyntaxJson5}, &LimitError{Limit: "string length", Max: 4, Offset: 5, Line: 1, Column: 6}},
+		{`[1, 2, {"a": 1, "b": 2}]`, ParseOptions{MaxElements: 3}, nil},
+		{`[1, 2, 3, 4]`, ParseOptions{MaxElements: 3}, &LimitError{Limit: "elements", Max: 3, Offset: 10, Line: 1, Column: 11}},
+		{`{"a": 1, "a": 2}`, ParseOptions{MaxElements: 1}, &LimitError{Limit: "elements", Max: 1, Offset: 9, Line: 1, Column: 10}},
+	} {
+		doc, err := NewJsonDocumentWithOptions([]byte(tc.input), tc.options)
+		if tc.err == nil {
+			xtesting.Equal(t, err, nil)
+			xtesting.NotEqual(t, doc, nil)
+			continue
+		}
+		limitErr, ok := err.(*LimitError)
+		xtesting.Equal(t, ok, true)
+		xtesting.Equal(t, *limitErr, *tc.err)
+		xtesting.Equal(t, errors.Is(err, ErrLimitExceeded), true)
+	}
+
+	_, err := NewJsonDocumentWithOptions([]byte(strings.Repeat("[", 100000)), ParseOptions{MaxDepth: 64})
+	xtesting.Equal(t, err.Error(), "Exceeded the max depth 64 at offset 64 (line 1, column 65)\n")
+}
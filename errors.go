@@ -0,0 +1,142 @@
+package jsonq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// The selected field or array index does not exist, check it by errors.Is.
+	ErrNotFound = errors.New("jsonq: not found")
+
+	// The selected value has an unexpected type, check it by errors.Is.
+	ErrTypeMismatch = errors.New("jsonq: type mismatch")
+
+	// The selector (or json path / json pointer) has a syntax error, check it by errors.Is.
+	ErrSyntax = errors.New("jsonq: syntax error")
+
+	// The selected object key matches multiple keys after normalization (see WithKeyNormalizer), check it by errors.Is.
+	ErrAmbiguousKey = errors.New("jsonq: ambiguous key")
+
+	// The document exceeds a limit in ParseOptions, check it by errors.Is, or get the details by errors.As with *LimitError.
+	ErrLimitExceeded = errors.New("jsonq: limit exceeded")
+)
+
+// An error with a kind (ErrNotFound / ErrTypeMismatch / ErrSyntax / ErrAmbiguousKey), the message is kept as it is.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// Create an error with a kind, the kind could be checked by errors.Is.
+func kindErrorf(kind error, format string, v ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, v...)}
+}
+
+// An error of a duplicated object key in a document parsed with RejectDuplicates, the offset (starts from 0) points to the
+// duplicated key, and the line and column start from 1.
+type DuplicateKeyError struct {
+	Key    string
+	Offset int
+	Line   int
+	Column int
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("Duplicate key \"%s\" at offset %d (line %d, column %d)\n", e.Key, e.Offset, e.Line, e.Column)
+}
+
+// An error of exceeding a limit in ParseOptions, the limit is one of "bytes", "depth", "string length" and "elements". The
+// offset (starts from 0) points to where the limit is exceeded, and the line and column start from 1.
+type LimitError struct {
+	Limit  string
+	Max    int
+	Offset int
+	Line   int
+	Column int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("Exceeded the max %s %d at offset %d (line %d, column %d)\n", e.Limit, e.Max, e.Offset, e.Line, e.Column)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// An error of parsing a document, it could be checked by errors.Is with ErrSyntax. The offset (starts from 0) points to
+// the unexpected character, and the line and column start from 1, the column counts characters.
+type ParseError struct {
+	Msg    string
+	Offset int
+	Line   int
+	Column int
+
+	// A short excerpt of the line around the offset, and a caret pointing to the offset in the next line, such as:
+	//     "b": [1 2]
+	//             ^
+	Excerpt string
+}
+
+// Create a ParseError at the offset of the input.
+func newParseError(data []byte, offset int, msg string) *ParseError {
+	line, column := position(data, offset)
+	return &ParseError{Msg: msg, Offset: offset, Line: line, Column: column, Excerpt: excerpt(data, offset)}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d\n", e.Msg, e.Line, e.Column)
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrSyntax
+}
+
+// The max count of characters before and after the offset in an excerpt.
+const excerptRadius = 30
+
+// Get the line around the offset with a caret, the tabs before the offset are kept in the caret line for alignment.
+func excerpt(data []byte, offset int) string {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := bytes.IndexByte(data[offset:], '\n')
+	if end == -1 {
+		end = len(data)
+	} else {
+		end += offset
+	}
+	before, after := []rune(string(data[start:offset])), []rune(strings.TrimRight(string(data[offset:end]), "\r"))
+
+	prefix, suffix := "", ""
+	if len(before) > excerptRadius {
+		before, prefix = before[len(before)-excerptRadius:], "..."
+	}
+	if len(after) > excerptRadius {
+		after, suffix = after[:excerptRadius], "..."
+	}
+
+	caret := &strings.Builder{}
+	caret.WriteString(strings.Repeat(" ", utf8.RuneCountInString(prefix)))
+	for _, r := range before {
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+	return prefix + string(before) + string(after) + suffix + "\n" + caret.String()
+}
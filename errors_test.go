@@ -0,0 +1,40 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	for _, tc := range []struct {
+		input   string
+		msg     string
+		offset  int
+		line    int
+		column  int
+		excerpt string
+	}{
+		{"", "Expected json string, got an empty string", 0, 1, 1, "\n^"},
+		{"  \n 1", "Expected [ or { as the json's first token, got \"1\"", 4, 2, 2, " 1\n ^"},
+		{"\n\n{\"a\": }", "Invalid character '}' looking for beginning of value", 8, 3, 7, "{\"a\": }\n      ^"},
+		{"[1, 2", "Unexpected end of JSON input", 5, 1, 6, "[1, 2\n     ^"},
+		{"{\r\n\t\"é\": 1 2}", "Invalid character '2' after object key:value pair", 12, 2, 9, "\t\"é\": 1 2}\n\t       ^"},
+		{"[1e999]", "Number 1e999 is out of range", 1, 1, 2, "[1e999]\n ^"},
+		{"[\"" + strings.Repeat("a", 40) + "\" \"" + strings.Repeat("b", 40) + "\"]", "Invalid character '\"' after array element", 44, 1, 45,
+			"..." + strings.Repeat("a", 28) + "\" \"" + strings.Repeat("b", 29) + "...\n" + strings.Repeat(" ", 33) + "^"},
+	} {
+		_, err := NewJsonDocument([]byte(tc.input))
+		parseErr, ok := err.(*ParseError)
+		xtesting.Equal(t, ok, true)
+		xtesting.Equal(t, *parseErr, ParseError{Msg: tc.msg, Offset: tc.offset, Line: tc.line, Column: tc.column, Excerpt: tc.excerpt})
+		xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	}
+
+	_, err := NewJsonDocumentWithOptions([]byte("{\n  a: 1,\n  b: ,\n}"), ParseOptions{Syntax: SyntaxJson5})
+	xtesting.Equal(t, err.Error(), "Invalid character ',' looking for beginning of value at line 3, column 6\n")
+	xtesting.Equal(t, err.(*ParseError).Excerpt, "  b: ,\n     ^")
+	_, err = NewJsonDocumentWithOptions([]byte("\n[1, 2"), ParseOptions{Lazy: true})
+	xtesting.Equal(t, err.(*ParseError).Line, 2)
+}
@@ -2,4 +2,7 @@ module github.com/Aoi-hosizora/jsonq
 
 go 1.13
 
-require github.com/Aoi-hosizora/ahlib v1.3.0
+require (
+	github.com/Aoi-hosizora/ahlib v1.3.0
+	github.com/peterh/liner v1.2.2
+)
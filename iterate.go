@@ -0,0 +1,158 @@
+package jsonq
+
+import (
+	"errors"
+	"sort"
+)
+
+// Iterate over the object or array selected by the tokens, fn is called with each key (a string for objects, and an
+// int index for arrays) and a JsonQuery rooted at the child, which could be queried just like the parent. The object
+// keys are visited in sorted order, the results of multiToken and starToken are treated as an array, and the iteration
+// stops at the first error returned by fn.
+func (j *JsonQuery) ForEach(fn func(key interface{}, child *JsonQuery) error, tokens ...interface{}) error {
+	keys, vals, err := j.children(tokens)
+	if err != nil {
+		return err
+	}
+	for idx, key := range keys {
+		if err := fn(key, j.withBlob(vals[idx])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Iterate over the object or array selected by a selector string, see ForEach.
+func (j *JsonQuery) ForEachBySelector(selectorString string, fn func(key interface{}, child *JsonQuery) error) error {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return err
+	}
+	return j.ForEach(fn, selector...)
+}
+
+// Get the sorted keys of the object selected by the tokens.
+func (j *JsonQuery) Keys(tokens ...interface{}) ([]string, error) {
+	res, err := j.Select(tokens...)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := interfaceToObject(res)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(obj), nil
+}
+
+// Get the sorted keys of the object selected by a selector string.
+func (j *JsonQuery) KeysBySelector(selectorString string) ([]string, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Keys(selector...)
+}
+
+// Get the values of the object or array selected by the tokens, in the order of ForEach.
+func (j *JsonQuery) Values(tokens ...interface{}) ([]interface{}, error) {
+	_, vals, err := j.children(tokens)
+	return vals, err
+}
+
+// Get the values of the object or array selected by a selector string, see Values.
+func (j *JsonQuery) ValuesBySelector(selectorString string) ([]interface{}, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Values(selector...)
+}
+
+// Get the count of fields of the object, or the length of the array selected by the tokens, the results of multiToken
+// and starToken are treated as an array.
+func (j *JsonQuery) Len(tokens ...interface{}) (int, error) {
+	vals, multi, err := j.rquery(j.blobFor(tokens), tokens...)
+	if err != nil {
+		return 0, err
+	}
+	if multi {
+		return len(vals), nil
+	}
+	switch val := vals[0].(type) {
+	case map[string]interface{}:
+		return len(val), nil
+	case []interface{}:
+		return len(val), nil
+	}
+	return 0, kindErrorf(ErrTypeMismatch, "Excepted an object or array value, got \"%v\"\n", vals[0])
+}
+
+// Get the count of fields of the object, or the length of the array selected by a selector string.
+func (j *JsonQuery) LenBySelector(selectorString string) (int, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return 0, err
+	}
+	return j.Len(selector...)
+}
+
+// Check if the tokens select an existing value (including null). It returns false if the error is ErrNotFound, and
+// returns other errors (such as ErrTypeMismatch) as they are.
+func (j *JsonQuery) Exists(tokens ...interface{}) (bool, error) {
+	_, err := j.Select(tokens...)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Check if a selector string selects an existing value, see Exists.
+func (j *JsonQuery) ExistsBySelector(selectorString string) (bool, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return false, err
+	}
+	return j.Exists(selector...)
+}
+
+// Select the keys and values of the children of the object or array selected by the tokens.
+func (j *JsonQuery) children(tokens []interface{}) ([]interface{}, []interface{}, error) {
+	vals, multi, err := j.rquery(j.blobFor(tokens), tokens...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var val interface{} = vals
+	if !multi {
+		val = vals[0]
+	}
+
+	switch val := val.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(val)
+		outKeys, outVals := make([]interface{}, len(keys)), make([]interface{}, len(keys))
+		for idx, key := range keys {
+			outKeys[idx], outVals[idx] = key, val[key]
+		}
+		return outKeys, outVals, nil
+	case []interface{}:
+		outKeys := make([]interface{}, len(val))
+		for idx := range val {
+			outKeys[idx] = idx
+		}
+		return outKeys, val, nil
+	}
+	return nil, nil, kindErrorf(ErrTypeMismatch, "Excepted an object or array value, got \"%v\"\n", val)
+}
+
+// Get the sorted keys of an object.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,37 @@
+//go:build go1.23
+
+package jsonq
+
+import (
+	"iter"
+)
+
+// Get an iterator over the object or array selected by the tokens, which yields the same keys and children as ForEach,
+// such as:
+//
+//	seq, err := jq.Iter("c", "f")
+//	for key, child := range seq {
+//		val, err := child.Int64("g")
+//	}
+func (j *JsonQuery) Iter(tokens ...interface{}) (iter.Seq2[interface{}, *JsonQuery], error) {
+	keys, vals, err := j.children(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(interface{}, *JsonQuery) bool) {
+		for idx, key := range keys {
+			if !yield(key, j.withBlob(vals[idx])) {
+				return
+			}
+		}
+	}, nil
+}
+
+// Get an iterator over the object or array selected by a selector string, see Iter.
+func (j *JsonQuery) IterBySelector(selectorString string) (iter.Seq2[interface{}, *JsonQuery], error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Iter(selector...)
+}
@@ -0,0 +1,42 @@
+//go:build go1.23
+
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestIter(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(objDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	seq, err := jq.Iter("c", "f")
+	xtesting.Equal(t, err, nil)
+	var is []string
+	for key, child := range seq {
+		xtesting.Equal(t, key, len(is))
+		is = append(is, handle(child.String("i")).(string))
+	}
+	xtesting.Equal(t, is, []string{"abc", "def", "ghi"})
+
+	seq, err = jq.IterBySelector("c j")
+	xtesting.Equal(t, err, nil)
+	var keys []interface{}
+	for key, child := range seq {
+		keys = append(keys, key)
+		if key == "l" {
+			xtesting.Equal(t, handle(child.Int64(1, 2)), int64(6))
+			break
+		}
+	}
+	xtesting.Equal(t, keys, []interface{}{"k", "l"})
+
+	_, err = jq.IterBySelector("a")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+}
@@ -0,0 +1,100 @@
+package jsonq
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(objDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	var keys []interface{}
+	var gs []int64
+	err = jq.ForEach(func(key interface{}, child *JsonQuery) error {
+		keys = append(keys, key)
+		gs = append(gs, handle(child.Int64("g")).(int64))
+		return nil
+	}, "c", "f")
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, keys, []interface{}{0, 1, 2})
+	xtesting.Equal(t, gs, []int64{123, 456, 789})
+
+	keys = nil
+	err = jq.ForEachBySelector("c", func(key interface{}, child *JsonQuery) error {
+		keys = append(keys, key)
+		if key == "f" {
+			xtesting.Equal(t, handle(child.Len()), 3)
+			xtesting.Equal(t, handle(child.StringBySelector("#-1 i")), "ghi")
+		}
+		return nil
+	})
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, keys, []interface{}{"e", "f", "j"})
+
+	// multiple results, early stop and errors
+	keys = nil
+	stop := errors.New("stop")
+	err = jq.ForEachBySelector("c f * i", func(key interface{}, child *JsonQuery) error {
+		keys = append(keys, fmt.Sprintf("%v=%v", key, handle(child.Select())))
+		if key == 1 {
+			return stop
+		}
+		return nil
+	})
+	xtesting.Equal(t, err, stop)
+	xtesting.Equal(t, keys, []interface{}{"0=abc", "1=def"})
+	err = jq.ForEach(func(interface{}, *JsonQuery) error { return nil }, "a")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	err = jq.ForEachBySelector("x", func(interface{}, *JsonQuery) error { return nil })
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	err = jq.ForEachBySelector("c |", func(interface{}, *JsonQuery) error { return nil })
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+
+	// children keep the query options
+	doc, _ = NewJsonDocument([]byte(`{"Users": [{"Name": "a"}]}`))
+	err = NewJsonQuery(doc, WithCaseInsensitiveKeys()).ForEach(func(key interface{}, child *JsonQuery) error {
+		xtesting.Equal(t, handle(child.String("name")), "a")
+		return nil
+	}, "users")
+	xtesting.Equal(t, err, nil)
+}
+
+func TestKeysLenExists(t *testing.T) {
+	doc, err := NewJsonDocumentWithOptions([]byte(objDoc), ParseOptions{Lazy: true})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	xtesting.Equal(t, handle(jq.Keys()), []string{"a", "c"})
+	xtesting.Equal(t, handle(jq.KeysBySelector("c j")), []string{"k", "l"})
+	_, err = jq.Keys("c", "f")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+
+	xtesting.Equal(t, handle(jq.Values("c", "f", 0)), []interface{}{123., 0.3, "abc"})
+	xtesting.Equal(t, handle(jq.ValuesBySelector("c j l #1")), []interface{}{4., 5., 6.})
+	xtesting.Equal(t, handle(jq.ValuesBySelector("c f * g+h")), []interface{}{123., 0.3, 456., 0.6, 789., 0.9})
+
+	xtesting.Equal(t, handle(jq.Len()), 2)
+	xtesting.Equal(t, handle(jq.Len("c", "f")), 3)
+	xtesting.Equal(t, handle(jq.LenBySelector("c f * g")), 3)
+	xtesting.Equal(t, handle(jq.LenBySelector("c f #5:")), 0)
+	_, err = jq.LenBySelector("a")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+
+	xtesting.Equal(t, handle(jq.Exists("c", "j", "k")), true)
+	xtesting.Equal(t, handle(jq.ExistsBySelector("c f #-1 i")), true)
+	xtesting.Equal(t, handle(jq.ExistsBySelector("c f #3")), false)
+	xtesting.Equal(t, handle(jq.ExistsBySelector("c x")), false)
+	_, err = jq.ExistsBySelector("a #0")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = jq.ExistsBySelector("c |")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+}
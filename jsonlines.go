@@ -0,0 +1,110 @@
+package jsonq
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// An error of a line in json lines, the line number starts from 1.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("Line %d: %s\n", e.Line, strings.TrimRight(e.Err.Error(), "\n"))
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Read json lines (newline-delimited json), a JsonDocument per line.
+type JsonLinesReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// Create a JsonLinesReader to read json lines.
+func NewJsonLinesReader(r io.Reader) *JsonLinesReader {
+	return &JsonLinesReader{r: bufio.NewReader(r)}
+}
+
+// Read the next document, blank lines are skipped. It returns io.EOF if there are no more lines, and returns a *LineError
+// if the line is malformed, in which case the reader could continue reading the next line.
+func (r *JsonLinesReader) Next() (*JsonDocument, error) {
+	for {
+		data, err := r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(data) == 0) {
+			return nil, err
+		}
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		doc, err := NewJsonDocument(data)
+		if err != nil {
+			return nil, &LineError{Line: r.line, Err: err}
+		}
+		return doc, nil
+	}
+}
+
+// Get the line number of the last read line, starts from 1.
+func (r *JsonLinesReader) Line() int {
+	return r.line
+}
+
+// Policy of handling malformed lines (the lines which could not be parsed), used in SelectJsonLines.
+type MalformedPolicy int
+
+const (
+	// Stop at the first malformed line, and return its *LineError.
+	FailOnMalformed MalformedPolicy = iota
+
+	// Skip malformed lines silently.
+	SkipMalformed
+
+	// Skip malformed lines, and return all of their *LineError after reading.
+	CollectMalformed
+)
+
+// Query every line of json lines by a compiled selector, and stream the results to fn with their line numbers. Only the
+// lines which could not be parsed are handled by the policy, and the error of querying a valid line (such as ErrNotFound)
+// is passed to fn with a nil value, so that fn could decide to ignore it or to stop. The error returned from fn stops the
+// reading.
+func SelectJsonLines(r io.Reader, selector *Selector, policy MalformedPolicy, fn func(line int, val interface{}, err error) error, options ...QueryOption) ([]*LineError, error) {
+	reader := NewJsonLinesReader(r)
+	malformed := make([]*LineError, 0)
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			return malformed, nil
+		}
+		if err != nil {
+			lineErr, ok := err.(*LineError)
+			if !ok { // io error
+				return malformed, err
+			}
+			switch policy {
+			case SkipMalformed:
+				continue
+			case CollectMalformed:
+				malformed = append(malformed, lineErr)
+				continue
+			default:
+				return malformed, lineErr
+			}
+		}
+
+		val, err := NewJsonQuery(doc, options...).SelectCompiled(selector)
+		if err := fn(reader.Line(), val, err); err != nil {
+			return malformed, err
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"io"
+	"strings"
+	"testing"
+)
+
+var linesDoc = `{"id": 1, "user": {"name": "a"}}
+{"id": 2, "user": {"name": "b"}}
+
+{"id": 3, "user": {"name": "c"
+{"id": 4, "user": {}}
+  {"id": 5, "user": {"name": "e"}}  ` + "\r\n"
+
+func TestJsonLinesReader(t *testing.T) {
+	reader := NewJsonLinesReader(strings.NewReader(linesDoc))
+	ids := make([]int64, 0)
+	lines := make([]int, 0)
+	for {
+		doc, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			lineErr, ok := err.(*LineError)
+			xtesting.Equal(t, ok, true)
+			xtesting.Equal(t, lineErr.Line, 4)
+			xtesting.Equal(t, strings.HasPrefix(lineErr.Error(), "Line 4: "), true)
+			xtesting.Equal(t, strings.Count(lineErr.Error(), "\n"), 1)
+			xtesting.Equal(t, strings.HasSuffix(lineErr.Error(), "\n"), true)
+			continue
+		}
+		id, _ := NewJsonQuery(doc).Int64("id")
+		ids = append(ids, id)
+		lines = append(lines, reader.Line())
+	}
+	xtesting.Equal(t, ids, []int64{1, 2, 4, 5})
+	xtesting.Equal(t, lines, []int{1, 2, 5, 6})
+}
+
+func TestSelectJsonLines(t *testing.T) {
+	sel := MustCompile("user name")
+	names := make([]interface{}, 0)
+	lines := make([]int, 0)
+	errLines := make([]int, 0)
+	collect := func(line int, val interface{}, err error) error {
+		if err != nil {
+			xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+			xtesting.Equal(t, val, nil)
+			errLines = append(errLines, line)
+			return nil
+		}
+		lines = append(lines, line)
+		names = append(names, val)
+		return nil
+	}
+
+	// the query errors of valid lines are passed to fn, whatever the policy is
+	malformed, err := SelectJsonLines(strings.NewReader(linesDoc), sel, SkipMalformed, collect)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, len(malformed), 0)
+	xtesting.Equal(t, names, []interface{}{"a", "b", "e"})
+	xtesting.Equal(t, lines, []int{1, 2, 6})
+	xtesting.Equal(t, errLines, []int{5})
+
+	names, lines, errLines = names[:0], lines[:0], errLines[:0]
+	malformed, err = SelectJsonLines(strings.NewReader(linesDoc), sel, CollectMalformed, collect)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, len(malformed), 1)
+	xtesting.Equal(t, malformed[0].Line, 4)
+	xtesting.Equal(t, errors.Is(malformed[0], ErrSyntax), true)
+	xtesting.Equal(t, names, []interface{}{"a", "b", "e"})
+	xtesting.Equal(t, errLines, []int{5})
+
+	names, lines, errLines = names[:0], lines[:0], errLines[:0]
+	_, err = SelectJsonLines(strings.NewReader(linesDoc), sel, FailOnMalformed, collect)
+	xtesting.Equal(t, err.(*LineError).Line, 4)
+	xtesting.Equal(t, names, []interface{}{"a", "b"})
+	xtesting.Equal(t, len(errLines), 0)
+
+	// stop by the error returned from fn
+	_, err = SelectJsonLines(strings.NewReader(linesDoc), sel, SkipMalformed, func(line int, val interface{}, err error) error {
+		return err
+	})
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	stop := errors.New("stop")
+	_, err = SelectJsonLines(strings.NewReader(linesDoc), MustCompile("ID"), FailOnMalformed, func(line int, val interface{}, err error) error {
+		return stop
+	}, WithCaseInsensitiveKeys())
+	xtesting.Equal(t, err, stop)
+}
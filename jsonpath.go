@@ -0,0 +1,225 @@
+package jsonq
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Parse a json path string (a subset of RFC 9535) to tokens which could be used in Select, such as "$.c.f[0].g".
+//
+// Supported segments: member ".name" and "['name']", index "[0]" and "[-1]", slice "[0:2]" and "[1:]", wildcard ".*"
+// and "[*]", and union "[0,1]" and "['a','b']". Recursive descent "..", filter "[?...]" and slice step are not supported.
+func ParseJsonPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, kindErrorf(ErrSyntax, "Expected $ as the json path's first char\n")
+	}
+
+	tokens := make([]interface{}, 0)
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, kindErrorf(ErrSyntax, "Recursive descent \"..\" is not supported in json path\n")
+		case rest[0] == '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, "*") {
+				tokens = append(tokens, All())
+				rest = rest[1:]
+				continue
+			}
+			n := strings.IndexAny(rest, ".[")
+			if n == -1 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return nil, kindErrorf(ErrSyntax, "Expected a member name after . in json path\n")
+			}
+			tokens = append(tokens, rest[:n])
+			rest = rest[n:]
+		case rest[0] == '[':
+			token, n, err := parseJsonPathBracket(rest)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			rest = rest[n:]
+		default:
+			return nil, kindErrorf(ErrSyntax, "Expected . or [ in json path, got \"%c\"\n", rest[0])
+		}
+	}
+	return tokens, nil
+}
+
+// Query json by a json path string, see ParseJsonPath.
+func (j *JsonQuery) SelectByJsonPath(path string) (interface{}, error) {
+	tokens, err := ParseJsonPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return j.Select(tokens...)
+}
+
+// Parse a bracket segment which starts with "[", returns the token and the length of the segment.
+func parseJsonPathBracket(s string) (interface{}, int, error) {
+	sels := make([]interface{}, 0)
+	pos := 1
+	for {
+		pos = skipJsonPathSpaces(s, pos)
+		if pos >= len(s) {
+			return nil, 0, kindErrorf(ErrSyntax, "Expected ] to close the bracket in json path\n")
+		}
+
+		switch ch := s[pos]; {
+		case ch == '*':
+			sels = append(sels, All())
+			pos++
+		case ch == '\'' || ch == '"':
+			name, n, err := parseJsonPathString(s[pos:])
+			if err != nil {
+				return nil, 0, err
+			}
+			sels = append(sels, name)
+			pos += n
+		case ch == '-' || ch == ':' || (ch >= '0' && ch <= '9'):
+			token, n, err := parseJsonPathIndex(s[pos:])
+			if err != nil {
+				return nil, 0, err
+			}
+			sels = append(sels, token)
+			pos += n
+		case ch == '?':
+			return nil, 0, kindErrorf(ErrSyntax, "Filter \"[?...]\" is not supported in json path\n")
+		default:
+			return nil, 0, kindErrorf(ErrSyntax, "Unexpected char \"%c\" in json path bracket\n", ch)
+		}
+
+		pos = skipJsonPathSpaces(s, pos)
+		if pos < len(s) && s[pos] == ',' {
+			pos++
+			continue
+		}
+		if pos < len(s) && s[pos] == ']' {
+			pos++
+			break
+		}
+		return nil, 0, kindErrorf(ErrSyntax, "Expected , or ] in json path bracket\n")
+	}
+
+	if len(sels) == 1 {
+		return sels[0], pos, nil
+	}
+	return Multi(sels...), pos, nil
+}
+
+// Parse an index "0" or a slice "0:100" / "5:" / ":-1", returns the token and the length of the index or slice.
+func parseJsonPathIndex(s string) (interface{}, int, error) {
+	pos := 0
+	parseInt := func() (int, bool, error) {
+		start := pos
+		if pos < len(s) && s[pos] == '-' {
+			pos++
+		}
+		for pos < len(s) && s[pos] >= '0' && s[pos] <= '9' {
+			pos++
+		}
+		if pos == start {
+			return 0, false, nil
+		}
+		num, err := strconv.Atoi(s[start:pos])
+		if err != nil {
+			return 0, false, kindErrorf(ErrSyntax, "Could not parse array index \"%s\" in json path\n", s[start:pos])
+		}
+		return num, true, nil
+	}
+
+	start, _, err := parseInt()
+	if err != nil {
+		return nil, 0, err
+	}
+	if pos >= len(s) || s[pos] != ':' {
+		return start, pos, nil
+	}
+	pos++
+	end, hasEnd, err := parseInt()
+	if err != nil {
+		return nil, 0, err
+	}
+	if pos < len(s) && s[pos] == ':' {
+		return nil, 0, kindErrorf(ErrSyntax, "Slice step is not supported in json path\n")
+	}
+	if !hasEnd {
+		return SliceFrom(start), pos, nil
+	}
+	return Slice(start, end), pos, nil
+}
+
+// Parse a quoted string which starts with ' or ", returns the unescaped string and the length of the quoted string.
+func parseJsonPathString(s string) (string, int, error) {
+	quote := s[0]
+	var buf strings.Builder
+	for pos := 1; pos < len(s); pos++ {
+		ch := s[pos]
+		if ch == quote {
+			return buf.String(), pos + 1, nil
+		}
+		if ch != '\\' {
+			buf.WriteByte(ch)
+			continue
+		}
+
+		pos++
+		if pos >= len(s) {
+			break
+		}
+		switch s[pos] {
+		case 'b':
+			buf.WriteByte('\b')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case 'u':
+			r, n, ok := parseUnicodeEscape(s[pos-1:])
+			if !ok {
+				return "", 0, kindErrorf(ErrSyntax, "Could not parse unicode escape in json path\n")
+			}
+			buf.WriteRune(r)
+			pos += n - 2
+		default: // \' \" \\ \/
+			buf.WriteByte(s[pos])
+		}
+	}
+	return "", 0, kindErrorf(ErrSyntax, "Expected %c to close the string in json path\n", quote)
+}
+
+// Parse "\uXXXX" or a surrogate pair "\uXXXX\uXXXX", returns the rune and the length of the escape.
+func parseUnicodeEscape(s string) (rune, int, bool) {
+	if len(s) < 6 || s[0] != '\\' || s[1] != 'u' {
+		return 0, 0, false
+	}
+	r1, err := strconv.ParseUint(s[2:6], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	if utf16.IsSurrogate(rune(r1)) && len(s) >= 12 && s[6] == '\\' && s[7] == 'u' {
+		if r2, err := strconv.ParseUint(s[8:12], 16, 16); err == nil {
+			if r := utf16.DecodeRune(rune(r1), rune(r2)); r != utf8.RuneError {
+				return r, 12, true
+			}
+		}
+	}
+	return rune(r1), 6, true
+}
+
+func skipJsonPathSpaces(s string, pos int) int {
+	for pos < len(s) && (s[pos] == ' ' || s[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,39 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+	"unsafe"
+)
+
+func TestJsonPath(t *testing.T) {
+	ret1, _ := ParseJsonPath("$.a.b[0][-1]['c d'][\"e\\\"f\"].*[*]")
+	xtesting.Equal(t, ret1, []interface{}{"a", "b", 0, -1, "c d", "e\"f", All(), All()})
+
+	ret2, _ := ParseJsonPath("$[0, 1]['a','\\u00e9\\n'][ 'x' ]")
+	xtesting.Equal(t, ret2, []interface{}{Multi(0, 1), Multi("a", "é\n"), "x"})
+
+	ret3, _ := ParseJsonPath("$")
+	xtesting.Equal(t, ret3, []interface{}{})
+
+	for _, path := range []string{"a.b", "$..a", "$.", "$[?(@.a)]", "$['a'", "$[0", "$[a]", "$x", "$[0 1]"} {
+		_, err := ParseJsonPath(path)
+		xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	}
+
+	bytes := *(*[]byte)(unsafe.Pointer(&objDoc))
+	doc, err := NewJsonDocument(bytes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+	val1 := handle(jq.SelectByJsonPath("$.c.f[0].h"))
+	val2 := handle(jq.SelectByJsonPath("$.c.f[*].g"))
+	val3 := handle(jq.SelectByJsonPath("$['c']['j']['l'][-1][0,2]"))
+
+	xtesting.Equal(t, val1, 0.3)
+	xtesting.Equal(t, val2, []interface{}{123., 456., 789.})
+	xtesting.Equal(t, val3, []interface{}{4., 6.})
+}
@@ -4,56 +4,182 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 )
 
 // Parse json string first for json query.
+//
+// A JsonDocument is never modified by this package after being created, so it could be queried by many goroutines
+// concurrently (the lazily parsed documents are guarded by a mutex). The values returned by queries share memory with
+// the document, and modifying them is a data race with other readers, use SyncDocument to update a document while
+// querying it.
 type JsonDocument struct {
 	// a interface of
 	// 1. `map[string]interface{}` (if it is an object-wrapped json)
 	// 2. `[]interface{}` (if it is an array-wrapped json)
 	blob interface{}
+
+	// a lazily parsed document, which is used instead of blob if it is not nil
+	lazy *lazyDocument
+
+	// spans of the values in the input, only for RetainSpans
+	spans *spanIndex
+}
+
+// Options for parsing a JsonDocument, used in NewJsonDocumentWithOptions.
+type ParseOptions struct {
+	// Parse the document lazily, the input is validated once, and only the subtrees touched by queries are decoded,
+	// which is faster if only a few fields of a large document are queried. It could not be used with the other options,
+	// which need the input to be fully decoded.
+	Lazy bool
+
+	// Syntax of the input, defaults to SyntaxJson. The errors of other syntaxes report the line and column.
+	Syntax Syntax
+
+	// Policy of handling duplicated object keys, defaults to KeepLastDuplicate.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// Limits of the input, zero means unlimited. They are checked while parsing, and a *LimitError is returned once a
+	// limit is exceeded. MaxDepth limits the nesting depth (the root is at depth 1), MaxBytes limits the size of the whole
+	// input, MaxStringLength limits the bytes of every decoded string including keys, and MaxElements limits the count of
+	// items in every array and fields in every object.
+	MaxDepth        int
+	MaxBytes        int
+	MaxStringLength int
+	MaxElements     int
+
+	// Retain the spans of all values in the input, so that the locations of the selected values could be queried by
+	// JsonQuery.Spans, it costs more memory.
+	RetainSpans bool
 }
 
-// Create a JsonDocument, handle json string first (`{` or `[`).
+// Check if the hand-written decoder is needed, rather than encoding/json.
+func (o *ParseOptions) needsDecoder() bool {
+	return o.Syntax != SyntaxJson || o.DuplicateKeys != KeepLastDuplicate ||
+		o.MaxDepth > 0 || o.MaxBytes > 0 || o.MaxStringLength > 0 || o.MaxElements > 0 || o.RetainSpans
+}
+
+// Create a JsonDocument, handle json string first (`{` or `[`). The parsing errors are *ParseError.
 func NewJsonDocument(data []byte) (*JsonDocument, error) {
-	data = bytes.TrimSpace(data)
-	if len(data) == 0 {
-		return nil, fmt.Errorf("expected json string, got an empty string")
+	lead := len(data) - len(bytes.TrimLeftFunc(data, unicode.IsSpace)) // offset of the trimmed data
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, newParseError(data, len(data), "Expected json string, got an empty string")
 	}
 
 	// object-wrapped
-	if data[0] == '{' {
+	if trimmed[0] == '{' {
 		obj := make(map[string]interface{})
-		err := json.Unmarshal(data, &obj)
+		err := json.Unmarshal(trimmed, &obj)
 		if err != nil {
-			return nil, err
+			return nil, toParseError(data, lead, err)
 		}
 		return &JsonDocument{blob: obj}, nil
 	}
 
 	// array-wrapped
-	if data[0] == '[' {
+	if trimmed[0] == '[' {
 		arr := make([]interface{}, 0)
-		err := json.Unmarshal(data, &arr)
+		err := json.Unmarshal(trimmed, &arr)
 		if err != nil {
-			return nil, err
+			return nil, toParseError(data, lead, err)
 		}
 		return &JsonDocument{blob: arr}, nil
 	}
 
 	// other start token
-	return nil, fmt.Errorf("expected [ or { as the json's first token, got \"%c\"", data[0])
+	return nil, newParseError(data, lead, fmt.Sprintf("Expected [ or { as the json's first token, got \"%c\"", trimmed[0]))
 }
 
-// Query json fields.
+// Convert an error of encoding/json to a *ParseError, the offsets of encoding/json are relative to data[lead:].
+func toParseError(data []byte, lead int, err error) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		msg := e.Error()
+		offset := int(e.Offset) // after reading the unexpected character
+		if offset > 0 && !strings.HasPrefix(msg, "unexpected end") {
+			offset--
+		}
+		return newParseError(data, lead+offset, strings.ToUpper(msg[:1])+msg[1:])
+	case *json.UnmarshalTypeError: // number out of range
+		number := strings.TrimPrefix(e.Value, "number ")
+		return newParseError(data, lead+int(e.Offset)-len(number), fmt.Sprintf("Number %s is out of range", number))
+	}
+	return err
+}
+
+// Create a JsonDocument with ParseOptions, see NewJsonDocument.
+func NewJsonDocumentWithOptions(data []byte, options ParseOptions) (*JsonDocument, error) {
+	if options.Lazy && options.needsDecoder() {
+		return nil, fmt.Errorf("Could not use ParseOptions.Lazy with other options\n")
+	}
+	if options.needsDecoder() {
+		dec := _NewDecoder(data, options)
+		blob, err := dec.Decode()
+		if err != nil {
+			return nil, err
+		}
+		doc := &JsonDocument{blob: blob}
+		if options.RetainSpans {
+			doc.spans = &spanIndex{data: data, blob: dec.lastSpan, containers: dec.containers}
+		}
+		return doc, nil
+	}
+	if !options.Lazy {
+		return NewJsonDocument(data)
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid(trimmed) {
+		return NewJsonDocument(data) // report the error
+	}
+	return &JsonDocument{lazy: newLazyDocument(trimmed)}, nil
+}
+
+// Get the fully decoded root value.
+func (d *JsonDocument) value() interface{} {
+	if d.lazy != nil {
+		return d.lazy.value()
+	}
+	return d.blob
+}
+
+// Query json fields, it is safe for concurrent use just like its JsonDocument.
 type JsonQuery struct {
 	// a json document that has been check (parse) correctly
 	doc *JsonDocument
+
+	// a function to normalize object keys and string tokens before comparing, nil means exact match
+	normalize func(key string) string
+}
+
+// Option for JsonQuery, used in NewJsonQuery.
+type QueryOption func(j *JsonQuery)
+
+// Create a QueryOption to normalize object keys and string tokens by the given function before looking up, such as
+// mapping "UserId", "userID" and "user_id" to "userid". Note that if two keys in the same object are normalized to the
+// same one, looking up them will return an error of ErrAmbiguousKey. Each lookup normalizes all the keys of the object
+// without caching, that is O(n) for an object of n keys, so prefer the exact match for large objects.
+func WithKeyNormalizer(normalize func(key string) string) QueryOption {
+	return func(j *JsonQuery) {
+		j.normalize = normalize
+	}
+}
+
+// Create a QueryOption to look up object keys case-insensitively, see WithKeyNormalizer.
+func WithCaseInsensitiveKeys() QueryOption {
+	return WithKeyNormalizer(strings.ToLower)
 }
 
 // Create a JsonQuery to query json.
-func NewJsonQuery(doc *JsonDocument) *JsonQuery {
-	return &JsonQuery{doc: doc}
+func NewJsonQuery(doc *JsonDocument, options ...QueryOption) *JsonQuery {
+	j := &JsonQuery{doc: doc}
+	for _, option := range options {
+		option(j)
+	}
+	return j
 }
 
 // Select multiple fields in the same layer -> "+".
@@ -66,21 +192,110 @@ func Multi(tokens ...interface{}) *multiToken {
 	return &multiToken{sels: tokens}
 }
 
-// Select all fields in the same layer -> "*".
+// Select all fields in the same layer -> "*", the fields of an object are in the order of keys.
 type starToken struct{}
 
-// Build a selector which will select all fields in the same layer.
+// Build a selector which will select all fields in the same layer, the fields of an object are in the order of keys.
 func All() *starToken {
 	return &starToken{}
 }
 
+// Select fields whose keys match a glob pattern in the same layer -> "~user_*".
+type keyGlobToken struct {
+	pattern string
+}
+
+// Build a key pattern selector which will select the fields whose keys match the glob pattern in the same layer, in
+// the order of keys. The pattern supports "*" (any characters), "?" (a single character) and "\" (escape).
+func KeyGlob(pattern string) *keyGlobToken {
+	return &keyGlobToken{pattern: pattern}
+}
+
+func (k *keyGlobToken) String() string {
+	return "~" + k.pattern
+}
+
+// Select fields whose keys match a regular expression in the same layer -> "~/^v\d+$/".
+type keyRegexToken struct {
+	re *regexp.Regexp
+}
+
+// Build a key pattern selector which will select the fields whose keys match the regular expression in the same layer,
+// in the order of keys. It panics if the expression could not be compiled, like regexp.MustCompile.
+func KeyRegex(expr string) *keyRegexToken {
+	return &keyRegexToken{re: regexp.MustCompile(expr)}
+}
+
+func (k *keyRegexToken) String() string {
+	return "~/" + k.re.String() + "/"
+}
+
+// Select a range of items in the same layer -> "#0:100".
+type sliceToken struct {
+	start int
+	end   int
+	open  bool // no end bound
+}
+
+// Build a slice selector which will select the items in [start, end) of an array in the same layer. Negative bounds
+// count from the end of the array, and the bounds are clamped to the array.
+func Slice(start, end int) *sliceToken {
+	return &sliceToken{start: start, end: end}
+}
+
+// Build a slice selector which will select the items from start to the end of an array in the same layer.
+func SliceFrom(start int) *sliceToken {
+	return &sliceToken{start: start, open: true}
+}
+
+func (s *sliceToken) String() string {
+	if s.open {
+		return fmt.Sprintf("#%d:", s.start)
+	}
+	return fmt.Sprintf("#%d:%d", s.start, s.end)
+}
+
+// Get the clamped bounds [start, end) of the slice for an array with the given length.
+func (s *sliceToken) bounds(length int) (int, int) {
+	clamp := func(idx int) int {
+		if idx < 0 {
+			idx += length
+		}
+		if idx < 0 {
+			return 0
+		} else if idx > length {
+			return length
+		}
+		return idx
+	}
+	start, end := clamp(s.start), length
+	if !s.open {
+		end = clamp(s.end)
+	}
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// Apply an aggregate function to the previous results -> "| name".
+type funcToken struct {
+	name string
+}
+
+// Build a function selector which will aggregate the previous results, such as "count", "sum", "avg", "min", "max",
+// "distinct", "first" and "last".
+func Func(name string) *funcToken {
+	return &funcToken{name: name}
+}
+
 // ========================
 // key code start from here
 // ========================
 
-// Query json by a slice of strings / integers / MultiTokens.
+// Query json by a slice of strings / integers / multiTokens / starTokens / funcTokens.
 func (j *JsonQuery) Select(tokens ...interface{}) (interface{}, error) {
-	vals, multi, err := rquery(j.doc.blob, tokens...)
+	vals, multi, err := j.rquery(j.blobFor(tokens), tokens...)
 	if err != nil {
 		return nil, err
 	}
@@ -99,63 +314,114 @@ func (j *JsonQuery) SelectBySelector(selectorString string) (interface{}, error)
 	return j.Select(selector...)
 }
 
+// Get the root blob for querying by the tokens, only the needed parts are decoded if the document is lazy.
+func (j *JsonQuery) blobFor(tokens []interface{}) interface{} {
+	if j.doc.lazy != nil {
+		return j.doc.lazy.blobFor(tokens, j.normalize)
+	}
+	return j.doc.blob
+}
+
+// Get the root blob for querying by many token paths, see blobFor.
+func (j *JsonQuery) blobForPaths(paths [][]interface{}) interface{} {
+	if j.doc.lazy != nil {
+		return j.doc.lazy.blobForPaths(paths, j.normalize)
+	}
+	return j.doc.blob
+}
+
+// Get the root blob for querying by the reference tokens of a json pointer, see blobFor.
+func (j *JsonQuery) blobForPointer(refs []string) interface{} {
+	if j.doc.lazy != nil {
+		return j.doc.lazy.blobFor(j.doc.lazy.pointerTokens(refs, j.normalize), j.normalize)
+	}
+	return j.doc.blob
+}
+
 // Repetition query: tokens []interface{}.
 //
 // If it is a SingleToken(string, integer), it will select fields in different layers.
-// If it is a multiToken(starToken will return error), it will select fields in the same layer.
+// If it is a multiToken, it will select fields in the same layer.
 // If it is a starToken, it will select all fields in the same layer.
-// Once have a multiToken or an starToken, that will return an array.
-func rquery(blob interface{}, tokens ...interface{}) ([]interface{}, bool, error) {
+// If it is a keyGlobToken or a keyRegexToken, it will select the matched fields in the same layer.
+// If it is a sliceToken, it will select a range of items in the same layer.
+// If it is a funcToken, it will aggregate all the previous results into a single value.
+// Once have a multiToken or an starToken or a key pattern token or a sliceToken, that will return an array.
+func (j *JsonQuery) rquery(blob interface{}, tokens ...interface{}) ([]interface{}, bool, error) {
 	vals := []interface{}{blob}
 	isArray := false
 	for _, token := range tokens {
-		// get a token (stok / mtok / atok) in different layers
-		mtok, isMul := token.(*multiToken)
-		_, isAll := token.(*starToken)
-
-		if !isMul && !isAll {
-			// current layer is a single token
-			for idx, val := range vals { // for all data
-				val, err := query(val, token)
+		var err error
+		vals, isArray, err = j.rstep(vals, isArray, token)
+		if err != nil {
+			return nil, isArray, err
+		}
+	}
+	return vals, isArray, nil
+}
+
+// Apply a token of rquery to the current values, the values of single tokens are replaced in place.
+func (j *JsonQuery) rstep(vals []interface{}, isArray bool, token interface{}) ([]interface{}, bool, error) {
+	if ftok, isFunc := token.(*funcToken); isFunc {
+		// current layer is a function token, aggregate the array result or the single array / object
+		members := vals
+		if !isArray {
+			var err error
+			members, err = queryAll(vals[0])
+			if err != nil {
+				return nil, isArray, err
+			}
+		}
+		val, err := aggregate(ftok.name, members)
+		if err != nil {
+			return nil, isArray, err
+		}
+		return []interface{}{val}, false, nil
+	}
+
+	// get a token (stok / mtok / atok / ptok) in different layers
+	mtok, isMul := token.(*multiToken)
+
+	if !isMul && !isExpandable(token) {
+		// current layer is a single token
+		for idx, val := range vals { // for all data
+			val, err := j.query(val, token)
+			if err != nil {
+				return nil, isArray, err
+			}
+			vals[idx] = val // replace values directly
+		}
+		return vals, isArray, nil
+	}
+
+	// current layer is a multi token / an star token / a key pattern token
+	isArray = true
+	tmpVal := make([]interface{}, 0)
+	sels := []interface{}{token}
+	if isMul {
+		sels = mtok.sels
+	}
+
+	// for all data in the current array
+	for _, val := range vals {
+		for _, stok := range sels {
+			// get the tokens in mtok (same layer first)
+			if isExpandable(stok) {
+				vals, err := queryExpand(val, stok)
 				if err != nil {
 					return nil, isArray, err
 				}
-				vals[idx] = val // replace values directly
-			}
-		} else {
-			// current layer is a multi token / an star token
-			isArray = true
-			tmpVal := make([]interface{}, 0)
-
-			// for all data in the current array
-			if isMul {
-				// current layer is a multi token
-				for _, val := range vals {
-					for _, stok := range mtok.sels {
-						// get a single token in mtok (same layer first)
-						val, err := query(val, stok)
-						if err != nil {
-							return nil, isArray, err
-						}
-						tmpVal = append(tmpVal, val) // append to a new value array
-					}
-				}
+				tmpVal = append(tmpVal, vals...) // append all fields to a new value array
 			} else {
-				// current layer is a star token
-				for _, val := range vals {
-					// get all fields
-					vals, err := queryAll(val)
-					if err != nil {
-						return nil, isArray, err
-					}
-					tmpVal = append(tmpVal, vals...) // append to a new value array
+				val, err := j.query(val, stok)
+				if err != nil {
+					return nil, isArray, err
 				}
+				tmpVal = append(tmpVal, val) // append to a new value array
 			}
-
-			vals = tmpVal // replace values entirely
 		}
 	}
-	return vals, isArray, nil
+	return tmpVal, isArray, nil // replace values entirely
 }
 
 // Query a single field: token interface{}.
@@ -163,15 +429,15 @@ func rquery(blob interface{}, tokens ...interface{}) ([]interface{}, bool, error
 // If it is an integer, it will select an item in the array.
 // If it is a string, it will select a field in the map.
 // If the index is out of bound, or the map does not contain field, it will return an error.
-func query(blob interface{}, token interface{}) (interface{}, error) {
+func (j *JsonQuery) query(blob interface{}, token interface{}) (interface{}, error) {
 	idx, ok := token.(int) // index
 	if ok {
 		arr, ok := blob.([]interface{}) // array
 		if !ok {
-			return nil, fmt.Errorf("Array index on non-array %v\n", blob)
+			return nil, kindErrorf(ErrTypeMismatch, "Array index on non-array %v\n", blob)
 		}
 		if len(arr) <= idx || idx <= -len(arr)-1 { // out of bound
-			return nil, fmt.Errorf("Array index %d on array %v out of bound\n", idx, blob)
+			return nil, kindErrorf(ErrNotFound, "Array index %d on array %v out of bound\n", idx, blob)
 		}
 		if idx < 0 {
 			idx += len(arr)
@@ -183,19 +449,91 @@ func query(blob interface{}, token interface{}) (interface{}, error) {
 	if ok {
 		obj, ok := blob.(map[string]interface{}) // object
 		if !ok {
-			return nil, fmt.Errorf("Object lookup \"%s\" on non-object %v\n", token, blob)
+			return nil, kindErrorf(ErrTypeMismatch, "Object lookup \"%s\" on non-object %v\n", token, blob)
+		}
+		if j.normalize != nil {
+			return j.lookupNormalized(obj, tok)
 		}
 		val, ok := obj[tok]
 		if !ok { // field not exist
-			return nil, fmt.Errorf("Object %v does not contain field \"%s\"\n", blob, token)
+			return nil, kindErrorf(ErrNotFound, "Object %v does not contain field \"%s\"\n", blob, token)
 		}
 		return val, nil
 	}
 
-	return nil, fmt.Errorf("Input %v is a non-array and non-object\n", blob)
+	return nil, kindErrorf(ErrTypeMismatch, "Input %v is a non-array and non-object\n", blob)
+}
+
+// Query a single field by normalized keys, returns an error if multiple keys are normalized to the same one. All the keys
+// are normalized in each lookup, because the objects of lazy documents are materialized per query and could not be
+// indexed once.
+func (j *JsonQuery) lookupNormalized(obj map[string]interface{}, token string) (interface{}, error) {
+	target := j.normalize(token)
+	keys := make([]string, 0, 1)
+	for k := range obj {
+		if j.normalize(k) == target {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 { // field not exist
+		return nil, kindErrorf(ErrNotFound, "Object %v does not contain field \"%s\"\n", obj, token)
+	}
+	if len(keys) > 1 { // keys collide
+		sort.Strings(keys)
+		return nil, kindErrorf(ErrAmbiguousKey, "Object keys \"%s\" collide as \"%s\" after normalization\n", strings.Join(keys, "\", \""), target)
+	}
+	return obj[keys[0]], nil
+}
+
+// Check if the token will select multiple fields in the same layer: starToken / keyGlobToken / keyRegexToken / sliceToken.
+func isExpandable(token interface{}) bool {
+	switch token.(type) {
+	case *starToken, *keyGlobToken, *keyRegexToken, *sliceToken:
+		return true
+	}
+	return false
 }
 
-// Query all fields: starToken.
+// Query multiple fields: starToken / keyGlobToken / keyRegexToken / sliceToken.
+func queryExpand(blob interface{}, token interface{}) ([]interface{}, error) {
+	switch tok := token.(type) {
+	case *sliceToken:
+		arr, ok := blob.([]interface{}) // array
+		if !ok {
+			return nil, kindErrorf(ErrTypeMismatch, "Array slice %v on non-array %v\n", token, blob)
+		}
+		start, end := tok.bounds(len(arr))
+		return arr[start:end], nil
+	case *keyGlobToken:
+		return queryKeys(blob, token, func(key string) bool { return matchGlob(tok.pattern, key) })
+	case *keyRegexToken:
+		return queryKeys(blob, token, tok.re.MatchString)
+	}
+	return queryAll(blob)
+}
+
+// Query the fields whose keys are matched: keyGlobToken / keyRegexToken, in the order of keys.
+func queryKeys(blob interface{}, token interface{}, match func(key string) bool) ([]interface{}, error) {
+	obj, ok := blob.(map[string]interface{}) // object
+	if !ok {
+		return nil, kindErrorf(ErrTypeMismatch, "Key pattern %v on non-object %v\n", token, blob)
+	}
+	keys := make([]string, 0)
+	for k := range obj {
+		if match(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]interface{}, len(keys))
+	for idx, k := range keys {
+		out[idx] = obj[k]
+	}
+	return out, nil
+}
+
+// Query all fields: starToken, the fields of an object are in the order of keys, so that the aggregate functions which
+// depend on the order (first, last and distinct) give the same result for the same document.
 func queryAll(blob interface{}) ([]interface{}, error) {
 	arr, ok := blob.([]interface{})
 	if ok {
@@ -204,14 +542,58 @@ func queryAll(blob interface{}) ([]interface{}, error) {
 
 	obj, ok := blob.(map[string]interface{})
 	if ok {
-		out := make([]interface{}, len(obj))
-		idx := 0
+		keys := make([]string, 0, len(obj))
 		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // map iteration order is random
+		out := make([]interface{}, len(obj))
+		for idx, k := range keys {
 			out[idx] = obj[k]
-			idx++
 		}
 		return out, nil
 	}
 
-	return nil, fmt.Errorf("Input %v is a non-array and non-object\n", blob)
+	return nil, kindErrorf(ErrTypeMismatch, "Input %v is a non-array and non-object\n", blob)
+}
+
+// Check if the key matches the glob pattern, "*" matches any characters, "?" matches a single character and "\" escapes
+// the next character.
+func matchGlob(pattern string, key string) bool {
+	p, k := []rune(pattern), []rune(key)
+	px, kx := 0, 0
+	nextPx, nextKx := -1, -1 // backtrack to the last "*"
+	for px < len(p) || kx < len(k) {
+		if px < len(p) {
+			switch c := p[px]; c {
+			case '*':
+				nextPx, nextKx = px, kx+1
+				px++
+				continue
+			case '?':
+				if kx < len(k) {
+					px++
+					kx++
+					continue
+				}
+			default:
+				width := 1
+				if c == '\\' && px+1 < len(p) {
+					c = p[px+1]
+					width = 2
+				}
+				if kx < len(k) && k[kx] == c {
+					px += width
+					kx++
+					continue
+				}
+			}
+		}
+		if nextKx > 0 && nextKx <= len(k) {
+			px, kx = nextPx, nextKx
+			continue
+		}
+		return false
+	}
+	return true
 }
@@ -1,9 +1,11 @@
 package jsonq
 
 import (
+	"errors"
 	"github.com/Aoi-hosizora/ahlib/xslice"
 	"github.com/Aoi-hosizora/ahlib/xtesting"
 	"log"
+	"strings"
 	"testing"
 	"unsafe"
 )
@@ -299,6 +301,88 @@ func TestSelector(t *testing.T) {
 	assert(t, val7, []interface{}{"hello world", "hello golang"})
 }
 
+func TestKeyPattern(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(`{"user_id": 1, "user_name": "a", "group_id": 2, "v1": "x", "v22": "y", "vv": "z", "*": 3, "a": {"b": [{"c1": 4, "c2": 5}]}}`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	jq := NewJsonQuery(doc)
+	val1 := handle(jq.SelectBySelector("~user_*"))
+	val2 := handle(jq.SelectBySelector("~*_id"))
+	val3 := handle(jq.SelectBySelector("~/^v\\d+$/"))
+	val4 := handle(jq.SelectBySelector("~user_?d+~v?+\\*"))
+	val5 := handle(jq.SelectBySelector("a b * ~c*"))
+	val6 := handle(jq.SelectBySelector("~*_id | sum"))
+	val7 := handle(jq.Select(KeyGlob("user_*")))
+	val8 := handle(jq.Select(Multi(KeyRegex("^a$"), KeyGlob("a")), KeyGlob("b*"), 0, "c2"))
+
+	xtesting.Equal(t, val1, []interface{}{1., "a"})
+	xtesting.Equal(t, val2, []interface{}{2., 1.})
+	xtesting.Equal(t, val3, []interface{}{"x", "y"})
+	xtesting.Equal(t, val4, []interface{}{1., "x", "z", 3.})
+	xtesting.Equal(t, val5, []interface{}{4., 5.})
+	xtesting.Equal(t, val6, 3.)
+	xtesting.Equal(t, val7, []interface{}{1., "a"})
+	xtesting.Equal(t, val8, []interface{}{5., 5.})
+
+	_, err = jq.Select("a", "b", KeyGlob("*"))
+	xtesting.NotEqual(t, err, nil)
+
+	// the patterns are opt-in by ~, so * ? and / in keys are plain
+	doc, _ = NewJsonDocument([]byte(`{"a*b": 1, "axb": 2, "a?": 3, "/api": 4, "~a": 5}`))
+	jq = NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.SelectBySelector("a*b")), 1.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("a?")), 3.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("/api")), 4.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("\\~a")), 5.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("~a*b")), []interface{}{1., 2.})
+
+	xtesting.Equal(t, matchGlob("a*c", "abbc"), true)
+	xtesting.Equal(t, matchGlob("a*c", "abbd"), false)
+	xtesting.Equal(t, matchGlob("*", ""), true)
+	xtesting.Equal(t, matchGlob("?", ""), false)
+	xtesting.Equal(t, matchGlob("a\\*", "a*"), true)
+	xtesting.Equal(t, matchGlob("a\\*", "ab"), false)
+	xtesting.Equal(t, matchGlob("*a*b", "xaxxab"), true)
+	xtesting.Equal(t, matchGlob("日?", "日本"), true)
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(`[{"UserId": 1, "Name": "a"}, {"userID": 2, "name": "b", "NAME": "c"}, {"user_id": 3}]`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	jq1 := NewJsonQuery(doc, WithCaseInsensitiveKeys())
+	val1 := handle(jq1.Select(0, "userid"))
+	val2 := handle(jq1.SelectBySelector("#1 USERID"))
+	val3 := handle(jq1.Select(0, "name"))
+	_, err = jq1.Select(1, "name")
+	xtesting.Equal(t, err.Error(), "Object keys \"NAME\", \"name\" collide as \"name\" after normalization\n")
+	xtesting.Equal(t, errors.Is(err, ErrAmbiguousKey), true)
+	_, err = jq1.Select(2, "userid")
+	xtesting.NotEqual(t, err, nil)
+
+	xtesting.Equal(t, val1, 1.)
+	xtesting.Equal(t, val2, 2.)
+	xtesting.Equal(t, val3, "a")
+
+	jq2 := NewJsonQuery(doc, WithKeyNormalizer(func(key string) string {
+		return strings.ToLower(strings.Replace(key, "_", "", -1))
+	}))
+	val4, _ := jq2.Int64sBySelector("#0+#1+#2 user_id")
+	xtesting.Equal(t, val4, []int64{1, 2, 3})
+
+	sorted, _ := jq2.SortBy([]*SortKey{Desc("userId")})
+	val5, _ := sorted.Int64s(All(), "USER_ID")
+	xtesting.Equal(t, val5, []int64{3, 2, 1})
+
+	jq3 := NewJsonQuery(doc)
+	_, err = jq3.Select(0, "userid")
+	xtesting.NotEqual(t, err, nil)
+}
+
 /*
 === RUN   TestObject
 --- PASS: TestObject (0.00s)
@@ -0,0 +1,243 @@
+package jsonq
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// A lazily parsed document, the children of a container are indexed when the container is touched for the first time,
+// and a value is decoded only when it is selected.
+type lazyDocument struct {
+	mu   sync.Mutex // guards the indexes and caches of all nodes
+	root *lazyNode
+}
+
+// A value in lazyDocument, it refers to the raw bytes in the input.
+type lazyNode struct {
+	raw []byte
+
+	indexed  bool
+	keys     []string    // keys of an object, in the order of input
+	children []*lazyNode // fields of an object, or items of an array
+
+	decoded bool
+	value   interface{}
+}
+
+// Create a lazyDocument from a validated json input.
+func newLazyDocument(data []byte) *lazyDocument {
+	return &lazyDocument{root: &lazyNode{raw: data}}
+}
+
+// Get the fully decoded root value.
+func (l *lazyDocument) value() interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.root.decode()
+}
+
+// Get a blob which only contains the parts needed by the tokens, it gives the same result as the fully decoded root
+// when querying by rquery with the tokens.
+func (l *lazyDocument) blobFor(tokens []interface{}, normalize func(string) string) interface{} {
+	return l.blobForPaths([][]interface{}{tokens}, normalize)
+}
+
+// Get a blob which only contains the parts needed by all the token paths, see blobFor.
+func (l *lazyDocument) blobForPaths(paths [][]interface{}, normalize func(string) string) interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.root.materialize(paths, normalize)
+}
+
+// Convert the reference tokens of a json pointer to tokens by the types of the nodes, an array index for an array and
+// an object key for an object, see SelectByJsonPointer. The conversion stops at the first reference which could not be
+// matched, so that the node is fully decoded and the error is reported by the query.
+func (l *lazyDocument) pointerTokens(refs []string, normalize func(string) string) []interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tokens := make([]interface{}, 0, len(refs))
+	node := l.root
+	for _, ref := range refs {
+		switch node.raw[0] {
+		case '[':
+			node.index()
+			idx, err := parsePointerIndex(ref)
+			if err != nil || idx >= len(node.children) {
+				return tokens
+			}
+			tokens, node = append(tokens, idx), node.children[idx]
+		case '{':
+			node.index()
+			var child *lazyNode
+			matched := make(map[string]bool)
+			for idx, key := range node.keys {
+				if lazyMatchKey(ref, key, normalize) {
+					child, matched[key] = node.children[idx], true // the later duplicated key overrides, like unmarshaling
+				}
+			}
+			if len(matched) != 1 {
+				return append(tokens, ref) // not found, or keys collide after normalization
+			}
+			tokens, node = append(tokens, ref), child
+		default:
+			return tokens
+		}
+	}
+	return tokens
+}
+
+// Decode the whole node and cache it.
+func (n *lazyNode) decode() interface{} {
+	if !n.decoded {
+		_ = json.Unmarshal(n.raw, &n.value) // the input has been validated
+		n.decoded = true
+	}
+	return n.value
+}
+
+// Index the children of an object or array node, the offsets are found by the raw scanner.
+func (n *lazyNode) index() {
+	if n.indexed {
+		return
+	}
+	n.indexed = true
+	if n.raw[0] == '[' {
+		_ = rawEachItem(n.raw, 0, func(_, item int) bool {
+			end, _ := rawSkipValue(n.raw, item)
+			n.children = append(n.children, &lazyNode{raw: n.raw[item:end]})
+			return true
+		})
+		return
+	}
+
+	i, _ := rawSkipSpaces(n.raw, 1)
+	for n.raw[i] == '"' {
+		keyEnd, _ := rawSkipString(n.raw, i)
+		var key string
+		_ = json.Unmarshal(n.raw[i:keyEnd], &key)
+		i, _ = rawSkipSpaces(n.raw, keyEnd)
+		start, _ := rawSkipSpaces(n.raw, i+1) // after ':'
+		end, _ := rawSkipValue(n.raw, start)
+		n.keys = append(n.keys, key)
+		n.children = append(n.children, &lazyNode{raw: n.raw[start:end]})
+		i, _ = rawSkipSpaces(n.raw, end)
+		if n.raw[i] == ',' {
+			i, _ = rawSkipSpaces(n.raw, i+1)
+		}
+	}
+}
+
+// Build a blob of the node which only contains the parts needed by the token paths. Arrays keep their lengths with nil
+// for the unneeded items, so that the indexes and slices are not changed. If a token could not be matched, or a path
+// ends at the node, the node is fully decoded, so that rquery reports the same error.
+func (n *lazyNode) materialize(paths [][]interface{}, normalize func(string) string) interface{} {
+	if n.decoded {
+		return n.decode()
+	}
+	for _, path := range paths {
+		if len(path) == 0 {
+			return n.decode()
+		}
+	}
+
+	switch n.raw[0] {
+	case '{':
+		n.index()
+		needed := make([][][]interface{}, len(n.keys)) // the rest paths of the needed children
+		for _, path := range paths {
+			for _, stok := range lazySels(path[0]) {
+				matched := false
+				for idx, key := range n.keys {
+					if lazyMatchKey(stok, key, normalize) {
+						needed[idx], matched = append(needed[idx], path[1:]), true
+					}
+				}
+				if _, ok := stok.(string); ok && !matched {
+					return n.decode() // not found
+				}
+				if !isLazyKeyToken(stok) {
+					return n.decode() // type mismatch or function
+				}
+			}
+		}
+		obj := make(map[string]interface{})
+		for idx, key := range n.keys {
+			if needed[idx] != nil { // the later duplicated key overrides, like unmarshaling
+				obj[key] = n.children[idx].materialize(needed[idx], normalize)
+			}
+		}
+		return obj
+	case '[':
+		n.index()
+		length := len(n.children)
+		needed := make([][][]interface{}, length)
+		for _, path := range paths {
+			for _, stok := range lazySels(path[0]) {
+				switch stok := stok.(type) {
+				case int:
+					if stok >= length || stok < -length {
+						return n.decode() // out of bound
+					}
+					if stok < 0 {
+						stok += length
+					}
+					needed[stok] = append(needed[stok], path[1:])
+				case *starToken:
+					for idx := range needed {
+						needed[idx] = append(needed[idx], path[1:])
+					}
+				case *sliceToken:
+					start, end := stok.bounds(length)
+					for idx := start; idx < end; idx++ {
+						needed[idx] = append(needed[idx], path[1:])
+					}
+				default:
+					return n.decode() // type mismatch or function
+				}
+			}
+		}
+		arr := make([]interface{}, length)
+		for idx, child := range n.children {
+			if needed[idx] != nil {
+				arr[idx] = child.materialize(needed[idx], normalize)
+			}
+		}
+		return arr
+	}
+	return n.decode()
+}
+
+// Get the selected tokens of a token in the same layer.
+func lazySels(token interface{}) []interface{} {
+	if mtok, ok := token.(*multiToken); ok {
+		return mtok.sels
+	}
+	return []interface{}{token}
+}
+
+// Check if the token selects fields of an object: string / starToken / keyGlobToken / keyRegexToken.
+func isLazyKeyToken(token interface{}) bool {
+	switch token.(type) {
+	case string, *starToken, *keyGlobToken, *keyRegexToken:
+		return true
+	}
+	return false
+}
+
+// Check if the token selects the field with the given key.
+func lazyMatchKey(token interface{}, key string, normalize func(string) string) bool {
+	switch tok := token.(type) {
+	case string:
+		if normalize != nil {
+			return normalize(tok) == normalize(key)
+		}
+		return tok == key
+	case *starToken:
+		return true
+	case *keyGlobToken:
+		return matchGlob(tok.pattern, key)
+	case *keyRegexToken:
+		return tok.re.MatchString(key)
+	}
+	return false
+}
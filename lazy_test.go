@@ -0,0 +1,97 @@
+package jsonq
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestLazyDocument(t *testing.T) {
+	for _, input := range []string{objDoc, arrDoc, sepDoc} {
+		doc, err := NewJsonDocument([]byte(input))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		lazyDoc, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Lazy: true})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		jq, lazyJq := NewJsonQuery(doc), NewJsonQuery(lazyDoc)
+		ciJq, lazyCiJq := NewJsonQuery(doc, WithCaseInsensitiveKeys()), NewJsonQuery(lazyDoc, WithCaseInsensitiveKeys())
+
+		for _, selector := range []string{
+			"a", "C", "c e", "c f * g", "c f #-1+#0 i", "c f #1: h", "c j l | count", "c j k x", "c ~/^[ef]$/ *", "c ~f* #0",
+			"#0", "#1", "#9", "#-1", "#1 a", "#1+#2 b c", "* a", "#3 b f #0:2 g", "#3 b f #3: #-1", "#2 b e", "#1 ~b? e", "* | count",
+			"\\#", "#1 \\#\\# #1", "#0 *", "#1 0",
+		} {
+			val, err := jq.SelectBySelector(selector)
+			lazyVal, lazyErr := lazyJq.SelectBySelector(selector)
+			xtesting.Equal(t, lazyVal, val)
+			xtesting.Equal(t, lazyErr, err)
+
+			val, err = ciJq.SelectBySelector(selector)
+			lazyVal, lazyErr = lazyCiJq.SelectBySelector(selector)
+			xtesting.Equal(t, lazyVal, val)
+			xtesting.Equal(t, lazyErr, err)
+		}
+
+		for _, pointer := range []string{
+			"", "/a", "/C", "/c/f/1/i", "/c/f/3", "/c/f/01", "/c/f/-", "/c/x/y", "/0", "/1/a", "/3/b/f/4/1", "/1/##/1", "/9",
+		} {
+			val, err := jq.SelectByJsonPointer(pointer)
+			lazyVal, lazyErr := lazyJq.SelectByJsonPointer(pointer)
+			xtesting.Equal(t, lazyVal, val)
+			xtesting.Equal(t, lazyErr, err)
+
+			val, err = ciJq.SelectByJsonPointer(pointer)
+			lazyVal, lazyErr = lazyCiJq.SelectByJsonPointer(pointer)
+			xtesting.Equal(t, lazyVal, val)
+			xtesting.Equal(t, lazyErr, err)
+		}
+
+		xtesting.Equal(t, lazyDoc.Equal(doc), true)
+	}
+
+	lazyDoc, _ := NewJsonDocumentWithOptions([]byte(objDoc), ParseOptions{Lazy: true})
+	lazyJq := NewJsonQuery(lazyDoc)
+	xtesting.Equal(t, handle(lazyJq.StringBySelector("c f #2 i")), "ghi")
+	xtesting.Equal(t, handle(lazyJq.SumBySelector("c f * g")), 1368.)
+	sorted, err := lazyJq.SortByBySelector("c f", DescBySelector("h"))
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, handle(sorted.StringBySelector("#0 i")), "ghi")
+
+	// only the touched subtrees are indexed and decoded
+	root := lazyDoc.lazy.root
+	xtesting.Equal(t, root.decoded, false)
+	xtesting.Equal(t, root.keys, []string{"a", "c"})
+	xtesting.Equal(t, root.children[0].indexed || root.children[0].decoded, false)
+	c := root.children[1]
+	xtesting.Equal(t, c.keys, []string{"e", "f", "j"})
+	xtesting.Equal(t, c.children[1].decoded, true)
+	xtesting.Equal(t, c.children[2].indexed || c.children[2].decoded, false)
+
+	// json pointers and batches also decode the touched subtrees only
+	lazyDoc, _ = NewJsonDocumentWithOptions([]byte(objDoc), ParseOptions{Lazy: true})
+	lazyJq = NewJsonQuery(lazyDoc)
+	xtesting.Equal(t, handle(lazyJq.SelectByJsonPointer("/c/f/1/i")), "def")
+	result := lazyJq.SelectBatch(MustCompileBatch(map[string]string{"k": "c j k", "l": "c j l #1 #2"}))
+	xtesting.Equal(t, result.Values, map[string]interface{}{"k": nil, "l": 6.})
+	root = lazyDoc.lazy.root
+	c = root.children[1]
+	xtesting.Equal(t, root.decoded || c.decoded, false)
+	xtesting.Equal(t, root.children[0].indexed || root.children[0].decoded, false)
+	xtesting.Equal(t, c.children[0].indexed || c.children[0].decoded, false)
+	xtesting.Equal(t, c.children[1].children[0].indexed || c.children[1].children[0].decoded, false)
+	xtesting.Equal(t, c.children[1].children[1].decoded, false)
+	xtesting.Equal(t, c.children[2].decoded, false)
+
+	_, err = NewJsonDocumentWithOptions([]byte(objDoc), ParseOptions{Lazy: true, RetainSpans: true})
+	xtesting.NotEqual(t, err, nil)
+	_, err = NewJsonDocumentWithOptions([]byte(objDoc), ParseOptions{Lazy: true, MaxDepth: 8})
+	xtesting.NotEqual(t, err, nil)
+
+	for _, input := range []string{"", "1", `{"a": }`, `[1, 2`} {
+		_, err := NewJsonDocumentWithOptions([]byte(input), ParseOptions{Lazy: true})
+		xtesting.NotEqual(t, err, nil)
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/Aoi-hosizora/jsonq/msgpack
+
+go 1.19
+
+require (
+	github.com/Aoi-hosizora/ahlib v1.3.0
+	github.com/Aoi-hosizora/jsonq v0.0.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+
+replace github.com/Aoi-hosizora/jsonq => ../
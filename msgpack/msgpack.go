@@ -0,0 +1,31 @@
+// Package msgpack creates json documents of jsonq from MessagePack, so that MessagePack could be queried by the same
+// selectors.
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/Aoi-hosizora/jsonq"
+	msgpackv5 "github.com/vmihailenco/msgpack/v5"
+)
+
+// Create a JsonDocument from MessagePack, the values are converted by jsonq.NewJsonDocumentFromValue:
+//
+// 1. all integer formats (fixint, int 8/16/32/64 and uint 8/16/32/64) and float 32/64 become float64;
+//
+// 2. bin 8/16/32 become base64 (standard encoding) strings, and str formats are kept as strings;
+//
+// 3. the timestamp extension (-1) becomes an RFC 3339 string in UTC, and other extensions are reported as an error;
+//
+// 4. map keys of any format are converted to strings like jsonq.NewJsonDocumentFromValue, such as 1 to "1".
+func NewJsonDocument(data []byte) (*jsonq.JsonDocument, error) {
+	dec := msgpackv5.NewDecoder(bytes.NewReader(data))
+	dec.SetMapDecoder(func(dec *msgpackv5.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return jsonq.NewJsonDocumentFromValue(v)
+}
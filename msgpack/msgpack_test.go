@@ -0,0 +1,60 @@
+package msgpack
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	msgpackv5 "github.com/vmihailenco/msgpack/v5"
+	"log"
+	"testing"
+	"time"
+)
+
+func handle(obj interface{}, err error) interface{} {
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return obj
+}
+
+func TestNewJsonDocument(t *testing.T) {
+	data, _ := msgpackv5.Marshal(map[string]interface{}{
+		"i8":   int8(-3),
+		"u64":  uint64(1 << 40),
+		"f32":  float32(0.5),
+		"bin":  []byte{1, 2},
+		"keys": map[int]string{1: "one", 2: "two"},
+		"time": time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC),
+		"arr":  []interface{}{"a", nil, true},
+	})
+	doc, err := NewJsonDocument(data)
+	xtesting.Equal(t, err, nil)
+	jq := jsonq.NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.Select("i8")), -3.)
+	xtesting.Equal(t, handle(jq.Int64("u64")), int64(1<<40))
+	xtesting.Equal(t, handle(jq.Select("f32")), 0.5)
+	xtesting.Equal(t, handle(jq.Select("bin")), "AQI=")
+	xtesting.Equal(t, handle(jq.Select("keys", "2")), "two")
+	xtesting.Equal(t, handle(jq.Select("time")), "2001-12-14T21:59:43Z")
+	xtesting.Equal(t, handle(jq.Select("arr")), []interface{}{"a", nil, true})
+
+	jsonDoc, _ := jsonq.NewJsonDocument([]byte(`[1, "a", {"b": [null]}]`))
+	data, _ = msgpackv5.Marshal([]interface{}{1, "a", map[string]interface{}{"b": []interface{}{nil}}})
+	doc, err = NewJsonDocument(data)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, doc.Equal(jsonDoc), true)
+
+	// msgpack decodes timestamps in the local time zone
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*3600)
+	defer func() { time.Local = local }()
+	data, _ = msgpackv5.Marshal(map[string]interface{}{"time": time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC)})
+	doc, err = NewJsonDocument(data)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, handle(jsonq.NewJsonQuery(doc).Select("time")), "2001-12-14T21:59:43Z")
+
+	_, err = NewJsonDocument([]byte{0x92, 0x01})
+	xtesting.NotEqual(t, err, nil)
+	data, _ = msgpackv5.Marshal("a")
+	_, err = NewJsonDocument(data)
+	xtesting.NotEqual(t, err, nil)
+}
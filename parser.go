@@ -3,8 +3,8 @@ package jsonq
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -21,6 +21,10 @@ const (
 	_ASTERISK // *
 	_PLUS     // +
 	_NUMBER   // #0
+	_PIPE     // |
+	_GLOB     // ~user_*
+	_REGEX    // ~/^v\d+$/
+	_SLICE    // #0:100
 )
 
 type _Scanner struct {
@@ -40,7 +44,7 @@ func (s *_Scanner) read() rune {
 }
 
 func (s *_Scanner) unread() {
-	_ = s.r.UnreadByte()
+	_ = s.r.UnreadRune()
 }
 
 func (s *_Scanner) Scan() (tok _Token, lit string, err error) {
@@ -52,6 +56,10 @@ func (s *_Scanner) Scan() (tok _Token, lit string, err error) {
 		return s.scanNumber() // start with #
 	} else if isStar(ch) { // -> all fields
 		return s.scanStar() // start with and only be *
+	} else if isTilde(ch) { // -> glob pattern or regex pattern
+		return s.scanPattern() // start with ~
+	} else if isPipe(ch) { // -> function, only when separated by ws
+		return s.scanPipe()
 	} else if isIdent(ch) { // -> string (include \)
 		s.unread() // release the previous char
 		return s.scanIdent()
@@ -63,7 +71,7 @@ func (s *_Scanner) Scan() (tok _Token, lit string, err error) {
 	case '+':
 		return _PLUS, "+", nil // -> new fields
 	default:
-		return _ILLEGAL, "", fmt.Errorf("Illegal char as the start with selector\n")
+		return _ILLEGAL, "", kindErrorf(ErrSyntax, "Illegal char as the start with selector\n")
 	}
 }
 
@@ -84,7 +92,8 @@ func (s *_Scanner) scanWhitespace() (tok _Token, lit string, err error) {
 
 func (s *_Scanner) scanNumber() (tok _Token, lit string, err error) {
 	var buf bytes.Buffer
-	minus := false
+	part := 0 // length of the current part, split by :
+	colon := false
 	for {
 		if ch := s.read(); ch == eof {
 			break
@@ -92,20 +101,31 @@ func (s *_Scanner) scanNumber() (tok _Token, lit string, err error) {
 			s.unread()
 			break
 		} else if isMinus(ch) {
-			if minus || len(buf.String()) != 0 {
-				return _ILLEGAL, "", fmt.Errorf("Could mix number and string after #\n")
+			if part != 0 {
+				return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could mix number and string after #\n")
 			} else {
-				minus = true
+				part++
+				buf.WriteRune(ch)
+			}
+		} else if isColon(ch) { // -> slice
+			if colon {
+				return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could not use more than one : after #\n")
+			} else {
+				colon = true
+				part = 0
 				buf.WriteRune(ch)
 			}
 		} else if isDigit(ch) {
+			part++
 			buf.WriteRune(ch)
 		} else {
-			return _ILLEGAL, "", fmt.Errorf("Could mix number and string after #\n")
+			return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could mix number and string after #\n")
 		}
 	}
 
-	if buf.String() == "" {
+	if colon {
+		return _SLICE, buf.String(), nil
+	} else if buf.String() == "" {
 		return _NUMBER, "0", nil
 	} else {
 		return _NUMBER, buf.String(), nil
@@ -120,14 +140,27 @@ func (s *_Scanner) scanStar() (tok _Token, lit string, err error) {
 			s.unread()
 			break
 		} else if isPlus(ch) { // next field
-			return _ILLEGAL, "", fmt.Errorf("Could not select the next field when use *\n")
+			return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could not select the next field when use *\n")
 		} else {
-			return _ILLEGAL, "", fmt.Errorf("Could not mix * and other token after *\n")
+			return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could not mix * and other token after *\n")
 		}
 	}
 	return _ASTERISK, "*", nil
 }
 
+func (s *_Scanner) scanPipe() (tok _Token, lit string, err error) {
+	ch := s.read()
+	if ch == eof {
+		return _PIPE, "|", nil
+	}
+	s.unread() // release the previous char
+	if isWhitespace(ch) {
+		return _PIPE, "|", nil
+	}
+	tok, lit, err = s.scanIdent() // a field name which starts with |, such as |a
+	return tok, "|" + lit, err
+}
+
 func (s *_Scanner) scanIdent() (tok _Token, lit string, err error) {
 	var buf bytes.Buffer
 	for {
@@ -136,7 +169,7 @@ func (s *_Scanner) scanIdent() (tok _Token, lit string, err error) {
 		} else if isWhitespace(ch) || isPlus(ch) { // next layer or next field
 			s.unread()
 			break
-		} else if isBackSlash(ch) { // escape (specially when start with # * and contain ws +)
+		} else if isBackSlash(ch) { // escape (specially when start with # * ~ | and contain ws +)
 			ch2 := s.read()
 			if ch2 == eof {
 				break
@@ -149,6 +182,73 @@ func (s *_Scanner) scanIdent() (tok _Token, lit string, err error) {
 	return _IDENT, buf.String(), nil
 }
 
+func (s *_Scanner) scanPattern() (tok _Token, lit string, err error) {
+	ch := s.read()
+	if isSlash(ch) { // -> regex pattern
+		return s.scanRegex() // start and end with /
+	}
+	s.unread() // release the previous char
+	if ch == eof || isWhitespace(ch) || isPlus(ch) {
+		return _ILLEGAL, "", kindErrorf(ErrSyntax, "Expected a glob pattern or a regular expression after ~\n")
+	}
+	return s.scanGlob()
+}
+
+func (s *_Scanner) scanGlob() (tok _Token, lit string, err error) {
+	var buf bytes.Buffer // glob pattern, with glob meta chars escaped
+	for {
+		if ch := s.read(); ch == eof {
+			break
+		} else if isWhitespace(ch) || isPlus(ch) { // next layer or next field
+			s.unread()
+			break
+		} else if isBackSlash(ch) { // escape (specially when contain ws + * ?)
+			ch2 := s.read()
+			if ch2 == eof {
+				break
+			}
+			if isStar(ch2) || isQuestion(ch2) || isBackSlash(ch2) { // keep the escape for glob meta chars
+				buf.WriteRune(ch)
+			}
+			buf.WriteRune(ch2)
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+	return _GLOB, buf.String(), nil
+}
+
+func (s *_Scanner) scanRegex() (tok _Token, lit string, err error) {
+	var buf bytes.Buffer
+	for {
+		ch := s.read()
+		if ch == eof {
+			return _ILLEGAL, "", kindErrorf(ErrSyntax, "Expected / to close the regular expression\n")
+		} else if isSlash(ch) { // end of regex
+			break
+		} else if isBackSlash(ch) { // only unescape \/, keep other escapes for regex
+			ch2 := s.read()
+			if ch2 == eof {
+				return _ILLEGAL, "", kindErrorf(ErrSyntax, "Expected / to close the regular expression\n")
+			}
+			if !isSlash(ch2) {
+				buf.WriteRune(ch)
+			}
+			buf.WriteRune(ch2)
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+
+	if ch := s.read(); ch != eof {
+		s.unread()
+		if !isWhitespace(ch) && !isPlus(ch) {
+			return _ILLEGAL, "", kindErrorf(ErrSyntax, "Could not mix regular expression and other token after ~/.../\n")
+		}
+	}
+	return _REGEX, buf.String(), nil
+}
+
 type _Parser struct {
 	s *_Scanner
 }
@@ -162,7 +262,8 @@ func (p *_Parser) readNextTok() (tok _Token, lit string, err error) {
 }
 
 func (p *_Parser) Parse() (selector []interface{}, err error) {
-	toks := []*multiToken{{}} // number / string / multiToken / starToken
+	toks := []*multiToken{{}} // number / string / multiToken / starToken / funcToken
+	pipe := false             // expect a function name after |
 
 out:
 	for {
@@ -171,27 +272,65 @@ out:
 			return nil, err
 		}
 
+		if pipe && tok != _WHITESPACE && tok != _IDENT {
+			return nil, kindErrorf(ErrSyntax, "Expected a function name after |\n")
+		}
+		if _, isFunc := lastSel(toks).(*funcToken); isFunc && tok != _WHITESPACE && tok != _PIPE && tok != _EOF {
+			return nil, kindErrorf(ErrSyntax, "Could not mix function and other token\n")
+		}
+
 		switch tok {
 		case _EOF:
 			break out
 		case _WHITESPACE:
+			if !pipe {
+				toks = append(toks, &multiToken{})
+			}
+		case _PIPE:
+			pipe = true
 			toks = append(toks, &multiToken{})
 		case _PLUS: // -> no need to handle, append to the last mtok directly
 		case _NUMBER:
 			num, err := strconv.Atoi(lit)
-			if err != nil {
-				panic(err)
+			if err != nil { // out of range
+				return nil, kindErrorf(ErrSyntax, "Could not parse array index #%s\n", lit)
 			}
 			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, num)
+		case _SLICE:
+			stok, err := parseSlice(lit)
+			if err != nil {
+				return nil, err
+			}
+			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, stok)
 		case _ASTERISK:
 			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, All())
+		case _GLOB:
+			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, KeyGlob(lit))
+		case _REGEX:
+			re, err := regexp.Compile(lit)
+			if err != nil {
+				return nil, kindErrorf(ErrSyntax, "Could not compile regular expression ~/%s/: %v\n", lit, err)
+			}
+			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, &keyRegexToken{re: re})
 		case _IDENT:
+			if pipe {
+				if !isAggregate(lit) {
+					return nil, kindErrorf(ErrSyntax, "Unknown function \"%s\"\n", lit)
+				}
+				pipe = false
+				toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, Func(lit))
+				continue
+			}
 			toks[len(toks)-1].sels = append(toks[len(toks)-1].sels, lit)
 		default:
 			panic("Illegal token type\n")
 		}
 	}
 
+	if pipe {
+		return nil, kindErrorf(ErrSyntax, "Expected a function name after |\n")
+	}
+
 	out := make([]interface{}, 0)
 	for _, mtok := range toks {
 		if len(mtok.sels) == 0 {
@@ -205,6 +344,50 @@ out:
 	return out, nil
 }
 
+// Parse a slice literal, such as "0:100", "5:", ":-1" and ":".
+func parseSlice(lit string) (*sliceToken, error) {
+	parts := strings.SplitN(lit, ":", 2)
+	bounds := make([]int, 2)
+	for idx, part := range parts {
+		if part == "" {
+			continue
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, kindErrorf(ErrSyntax, "Could not parse array slice #%s\n", lit)
+		}
+		bounds[idx] = num
+	}
+	if parts[1] == "" {
+		return SliceFrom(bounds[0]), nil
+	}
+	return Slice(bounds[0], bounds[1]), nil
+}
+
+// Escape a field name to be used in a selector string, such as "a b" -> "a\\ b" and "#0" -> "\\#0". Note that "|" is
+// only a function pipe when it is separated by whitespaces, so it is escaped only at the start of the field name, and an
+// empty field name could not be represented in a selector string.
+func Escape(key string) string {
+	var buf strings.Builder
+	for idx, ch := range key {
+		if isWhitespace(ch) || isPlus(ch) || isBackSlash(ch) ||
+			(idx == 0 && (isSharp(ch) || isStar(ch) || isTilde(ch) || isPipe(ch))) {
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(ch)
+	}
+	return buf.String()
+}
+
+// Get the last selected token in the last layer, returns nil if the layer is empty.
+func lastSel(toks []*multiToken) interface{} {
+	sels := toks[len(toks)-1].sels
+	if len(sels) == 0 {
+		return nil
+	}
+	return sels[len(sels)-1]
+}
+
 func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n'
 }
@@ -213,6 +396,22 @@ func isPlus(ch rune) bool {
 	return ch == '+'
 }
 
+func isPipe(ch rune) bool {
+	return ch == '|'
+}
+
+func isSlash(ch rune) bool {
+	return ch == '/'
+}
+
+func isQuestion(ch rune) bool {
+	return ch == '?'
+}
+
+func isColon(ch rune) bool {
+	return ch == ':'
+}
+
 func isSharp(ch rune) bool {
 	return ch == '#'
 }
@@ -221,8 +420,12 @@ func isStar(ch rune) bool {
 	return ch == '*'
 }
 
+func isTilde(ch rune) bool {
+	return ch == '~'
+}
+
 func isIdent(ch rune) bool {
-	return ch != '#' && ch != '*' && ch != ' ' && ch != '+' && ch != eof
+	return ch != '#' && ch != '*' && ch != '~' && ch != ' ' && ch != '+' && ch != '|' && ch != eof
 }
 
 func isDigit(ch rune) bool {
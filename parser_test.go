@@ -26,4 +26,55 @@ func TestParser(t *testing.T) {
 
 	ret7, _ := _NewParser("\\\\+\\\\\\#+\\\\\\##+\\\\+\\\\\\++\\\\\\\\#0").Parse()
 	xtesting.Equal(t, ret7, []interface{}{Multi("\\", "\\#", "\\##", "\\", "\\+", "\\\\#0")})
+
+	ret8, _ := _NewParser("a b+c * | sum \\|x #0 | count | first").Parse()
+	xtesting.Equal(t, ret8, []interface{}{"a", Multi("b", "c"), All(), Func("sum"), "|x", 0, Func("count"), Func("first")})
+
+	ret11, _ := _NewParser("a|b |c c| a||b+d|e \\| ||").Parse()
+	xtesting.Equal(t, ret11, []interface{}{"a|b", "|c", "c|", Multi("a||b", "d|e"), "|", "||"})
+
+	_, err := _NewParser("a | sum+count").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("a | #0").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("a | unknown").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("a | count|first").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("#0|count").Parse()
+	xtesting.NotEqual(t, err, nil)
+
+	ret9, _ := _NewParser("~user_* ~*_id ~a?c+\\*x ~/^v\\d+$/+~/a\\/b/ /c ~/x/ | count").Parse()
+	xtesting.Equal(t, ret9, []interface{}{KeyGlob("user_*"), KeyGlob("*_id"), Multi(KeyGlob("a?c"), "*x"), Multi(KeyRegex("^v\\d+$"), KeyRegex("a/b")), "/c", KeyRegex("x"), Func("count")})
+
+	ret10, _ := _NewParser("a*b a?b /api/ b~ \\~a ~a\\*\\ b+~\\\\").Parse()
+	xtesting.Equal(t, ret10, []interface{}{"a*b", "a?b", "/api/", "b~", "~a", Multi(KeyGlob("a\\* b"), KeyGlob("\\\\"))})
+
+	_, err = _NewParser("~/abc").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("~/a/b").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("~/(/").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("a ~ b").Parse()
+	xtesting.NotEqual(t, err, nil)
+	_, err = _NewParser("*x").Parse()
+	xtesting.NotEqual(t, err, nil)
+}
+
+func TestEscape(t *testing.T) {
+	xtesting.Equal(t, Escape("a b"), "a\\ b")
+	xtesting.Equal(t, Escape("#0"), "\\#0")
+	xtesting.Equal(t, Escape("a#0"), "a#0")
+	xtesting.Equal(t, Escape("/a/"), "/a/")
+	xtesting.Equal(t, Escape("*a*"), "\\*a*")
+	xtesting.Equal(t, Escape("~a~"), "\\~a~")
+	xtesting.Equal(t, Escape("a+b|c*d?e\\f"), "a\\+b|c*d?e\\\\f")
+	xtesting.Equal(t, Escape("|a|"), "\\|a|")
+
+	for _, key := range []string{"a", "a b", " ", "#", "##", "#0", "*", "**", "+", "|", "|a", "a|b", "a | b", "/", "/a/", "a\\", "\\#", "user_*", "a?", "~", "~/a/", "日本 語", "\t\n"} {
+		ret, err := _NewParser(Escape(key)).Parse()
+		xtesting.Equal(t, err, nil)
+		xtesting.Equal(t, ret, []interface{}{key})
+	}
 }
@@ -0,0 +1,59 @@
+package jsonq
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Query json by a json pointer string (RFC 6901), such as "/c/f/0/g". An empty string means the root.
+//
+// A reference token is used as an array index if the current value is an array, otherwise it is used as an object key.
+func (j *JsonQuery) SelectByJsonPointer(pointer string) (interface{}, error) {
+	if pointer == "" {
+		return j.blobFor(nil), nil
+	}
+	if pointer[0] != '/' {
+		return nil, kindErrorf(ErrSyntax, "Expected / as the json pointer's first char, got \"%c\"\n", pointer[0])
+	}
+
+	refs := strings.Split(pointer[1:], "/")
+	for idx, ref := range refs {
+		refs[idx] = strings.Replace(strings.Replace(ref, "~1", "/", -1), "~0", "~", -1)
+	}
+	val := j.blobForPointer(refs)
+	for _, ref := range refs {
+		var token interface{} = ref
+		if arr, ok := val.([]interface{}); ok {
+			idx, err := parsePointerIndex(ref)
+			if err != nil {
+				return nil, err
+			}
+			if idx >= len(arr) {
+				return nil, kindErrorf(ErrNotFound, "Array index %d on array %v out of bound\n", idx, arr)
+			}
+			token = idx
+		}
+
+		var err error
+		val, err = j.query(val, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// Parse an array index in json pointer, which must be "0" or a number without leading zeros.
+func parsePointerIndex(ref string) (int, error) {
+	if ref == "-" {
+		return 0, kindErrorf(ErrNotFound, "Array index \"-\" refers to a nonexistent element\n")
+	}
+	if ref == "" || (len(ref) > 1 && ref[0] == '0') || strings.TrimLeft(ref, "0123456789") != "" {
+		return 0, kindErrorf(ErrSyntax, "Could not parse array index \"%s\" in json pointer\n", ref)
+	}
+	idx, err := strconv.Atoi(ref)
+	if err != nil {
+		return 0, kindErrorf(ErrSyntax, "Could not parse array index \"%s\" in json pointer\n", ref)
+	}
+	return idx, nil
+}
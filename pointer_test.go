@@ -0,0 +1,50 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+	"unsafe"
+)
+
+func TestJsonPointer(t *testing.T) {
+	bytes := *(*[]byte)(unsafe.Pointer(&objDoc))
+	doc, err := NewJsonDocument(bytes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	jq := NewJsonQuery(doc)
+	val1 := handle(jq.SelectByJsonPointer("/a"))
+	val2 := handle(jq.SelectByJsonPointer("/c/f/0/h"))
+	val3 := handle(jq.SelectByJsonPointer("/c/j/l/1/2"))
+	val4 := handle(jq.SelectByJsonPointer("/c/j/k"))
+	val5 := handle(jq.SelectByJsonPointer(""))
+
+	xtesting.Equal(t, val1, "b")
+	xtesting.Equal(t, val2, 0.3)
+	xtesting.Equal(t, val3, 6.)
+	xtesting.Equal(t, val4, nil)
+	xtesting.Equal(t, val5, doc.blob)
+
+	doc2, _ := NewJsonDocument([]byte(`{"a/b": {"m~n": 1}, "0": [0, 1]}`))
+	jq2 := NewJsonQuery(doc2)
+	val6 := handle(jq2.SelectByJsonPointer("/a~1b/m~0n"))
+	val7 := handle(jq2.SelectByJsonPointer("/0/1"))
+	xtesting.Equal(t, val6, 1.)
+	xtesting.Equal(t, val7, 1.)
+
+	_, err = jq2.SelectByJsonPointer("a")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = jq2.SelectByJsonPointer("/0/01")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = jq2.SelectByJsonPointer("/0/2")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = jq2.SelectByJsonPointer("/0/-")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = jq2.SelectByJsonPointer("/b")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = jq2.SelectByJsonPointer("/a~1b/m~0n/x")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+}
@@ -0,0 +1,377 @@
+package jsonq
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// A result selected from raw json bytes, the value is converted to a typed value lazily, only when a getter is called.
+type RawResult struct {
+	raw []byte
+}
+
+// Get the raw bytes of the value, which shares the memory with the input.
+func (r RawResult) Raw() []byte {
+	return r.raw
+}
+
+// Check if the value is null.
+func (r RawResult) IsNull() bool {
+	return string(r.raw) == "null"
+}
+
+// Convert the value to a bool.
+func (r RawResult) Bool() (bool, error) {
+	switch string(r.raw) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, kindErrorf(ErrTypeMismatch, "Excepted a bool value, got \"%s\"\n", r.raw)
+}
+
+// Convert the value to an int64, the fraction of a number is truncated like Int64.
+func (r RawResult) Int64() (int64, error) {
+	if !isRawNumber(r.raw) {
+		return 0, kindErrorf(ErrTypeMismatch, "Excepted an int64 value, got \"%s\"\n", r.raw)
+	}
+	if i, err := strconv.ParseInt(string(r.raw), 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(string(r.raw), 64)
+	if err != nil {
+		return 0, kindErrorf(ErrTypeMismatch, "Excepted an int64 value, got \"%s\"\n", r.raw)
+	}
+	return int64(f), nil
+}
+
+// Convert the value to a float64.
+func (r RawResult) Float64() (float64, error) {
+	if !isRawNumber(r.raw) {
+		return 0, kindErrorf(ErrTypeMismatch, "Excepted a float64 value, got \"%s\"\n", r.raw)
+	}
+	f, err := strconv.ParseFloat(string(r.raw), 64)
+	if err != nil {
+		return 0, kindErrorf(ErrTypeMismatch, "Excepted a float64 value, got \"%s\"\n", r.raw)
+	}
+	return f, nil
+}
+
+// Convert the value to a string, the escapes are decoded.
+func (r RawResult) String() (string, error) {
+	if len(r.raw) < 2 || r.raw[0] != '"' {
+		return "", kindErrorf(ErrTypeMismatch, "Excepted a string value, got \"%s\"\n", r.raw)
+	}
+	if bytes.IndexByte(r.raw, '\\') == -1 {
+		return string(r.raw[1 : len(r.raw)-1]), nil
+	}
+	var s string
+	if err := json.Unmarshal(r.raw, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// Unmarshal the value to the same form as Select returns.
+func (r RawResult) Value() (interface{}, error) {
+	var val interface{}
+	if err := json.Unmarshal(r.raw, &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// Check if the raw value is a number, without checking its format.
+func isRawNumber(raw []byte) bool {
+	return len(raw) > 0 && (raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9'))
+}
+
+// Check if the character could be a part of a number.
+func isRawNumberChar(c byte) bool {
+	return (c >= '0' && c <= '9') || c == '+' || c == '-' || c == '.' || c == 'e' || c == 'E'
+}
+
+// ===========================================================================
+
+// Query raw json bytes by single tokens (integers and strings) without unmarshaling, only the values on the path are
+// scanned, and the others are skipped. The result is the same as Select, but the malformed parts which are skipped may
+// not be reported, and the tokens that select multiple values (multi, star, key pattern, slice and function) are not
+// supported.
+func SelectRaw(data []byte, tokens ...interface{}) (RawResult, error) {
+	for _, token := range tokens {
+		switch token.(type) {
+		case int, string:
+		default:
+			return RawResult{}, kindErrorf(ErrSyntax, "Token %v is not supported in raw query\n", token)
+		}
+	}
+
+	start, err := rawSkipSpaces(data, 0)
+	if err != nil {
+		return RawResult{}, err
+	}
+	for _, token := range tokens {
+		switch tok := token.(type) {
+		case int:
+			start, err = rawQueryIndex(data, start, tok)
+		case string:
+			start, err = rawQueryKey(data, start, tok)
+		}
+		if err != nil {
+			return RawResult{}, err
+		}
+	}
+	end, err := rawSkipValue(data, start)
+	if err != nil {
+		return RawResult{}, err
+	}
+	return RawResult{raw: data[start:end]}, nil
+}
+
+// Query raw json bytes by a selector string, see SelectRaw.
+func SelectRawBySelector(data []byte, selectorString string) (RawResult, error) {
+	tokens, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return RawResult{}, err
+	}
+	return SelectRaw(data, tokens...)
+}
+
+// Query raw json bytes by a compiled selector, see SelectRaw.
+func SelectRawCompiled(data []byte, selector *Selector) (RawResult, error) {
+	return SelectRaw(data, selector.tokens...)
+}
+
+// Query the field of the object at data[start:], and return the start of the field value. The last one is selected if the
+// key is duplicated, just like unmarshaling.
+func rawQueryKey(data []byte, start int, key string) (int, error) {
+	if data[start] != '{' {
+		return 0, kindErrorf(ErrTypeMismatch, "Object lookup \"%s\" on non-object %s\n", key, rawExcerpt(data, start))
+	}
+	found := -1
+	i, err := rawSkipSpaces(data, start+1)
+	if err != nil {
+		return 0, err
+	}
+	if data[i] == '}' {
+		return 0, kindErrorf(ErrNotFound, "Object {} does not contain field \"%s\"\n", key)
+	}
+	for {
+		if data[i] != '"' {
+			return 0, rawSyntaxError(data, i)
+		}
+		keyEnd, err := rawSkipString(data, i)
+		if err != nil {
+			return 0, err
+		}
+		matched, err := rawKeyEqual(data[i:keyEnd], key)
+		if err != nil {
+			return 0, err
+		}
+		if i, err = rawSkipSpaces(data, keyEnd); err != nil {
+			return 0, err
+		}
+		if data[i] != ':' {
+			return 0, rawSyntaxError(data, i)
+		}
+		if i, err = rawSkipSpaces(data, i+1); err != nil {
+			return 0, err
+		}
+		if matched {
+			found = i
+		}
+		if i, err = rawSkipValue(data, i); err != nil {
+			return 0, err
+		}
+		if i, err = rawSkipSpaces(data, i); err != nil {
+			return 0, err
+		}
+		switch data[i] {
+		case ',':
+			if i, err = rawSkipSpaces(data, i+1); err != nil {
+				return 0, err
+			}
+		case '}':
+			if found == -1 {
+				return 0, kindErrorf(ErrNotFound, "Object %s does not contain field \"%s\"\n", rawExcerpt(data, start), key)
+			}
+			return found, nil
+		default:
+			return 0, rawSyntaxError(data, i)
+		}
+	}
+}
+
+// Query the item of the array at data[start:], and return the start of the item. Negative index counts from the end, in
+// which case the array is scanned twice.
+func rawQueryIndex(data []byte, start int, idx int) (int, error) {
+	if data[start] != '[' {
+		return 0, kindErrorf(ErrTypeMismatch, "Array index on non-array %s\n", rawExcerpt(data, start))
+	}
+	if idx < 0 {
+		length, err := rawArrayLength(data, start)
+		if err != nil {
+			return 0, err
+		}
+		if idx+length < 0 { // out of bound
+			return 0, kindErrorf(ErrNotFound, "Array index %d on array %s out of bound\n", idx, rawExcerpt(data, start))
+		}
+		idx += length
+	}
+
+	found := -1
+	err := rawEachItem(data, start, func(i, item int) bool {
+		if i == idx {
+			found = item
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if found == -1 { // out of bound
+		return 0, kindErrorf(ErrNotFound, "Array index %d on array %s out of bound\n", idx, rawExcerpt(data, start))
+	}
+	return found, nil
+}
+
+// Get the length of the array at data[start:].
+func rawArrayLength(data []byte, start int) (int, error) {
+	length := 0
+	err := rawEachItem(data, start, func(int, int) bool {
+		length++
+		return true
+	})
+	return length, err
+}
+
+// Call fn with the index and the start of every item of the array at data[start:], until fn returns false.
+func rawEachItem(data []byte, start int, fn func(i, item int) bool) error {
+	i, err := rawSkipSpaces(data, start+1)
+	if err != nil {
+		return err
+	}
+	if data[i] == ']' {
+		return nil
+	}
+	for idx := 0; ; idx++ {
+		if !fn(idx, i) {
+			return nil
+		}
+		if i, err = rawSkipValue(data, i); err != nil {
+			return err
+		}
+		if i, err = rawSkipSpaces(data, i); err != nil {
+			return err
+		}
+		switch data[i] {
+		case ',':
+			if i, err = rawSkipSpaces(data, i+1); err != nil {
+				return err
+			}
+		case ']':
+			return nil
+		default:
+			return rawSyntaxError(data, i)
+		}
+	}
+}
+
+// Check if the quoted raw key equals to the key, it only unquotes the raw key if it has escapes.
+func rawKeyEqual(quoted []byte, key string) (bool, error) {
+	raw := quoted[1 : len(quoted)-1]
+	if bytes.IndexByte(raw, '\\') == -1 {
+		return string(raw) == key, nil
+	}
+	var s string
+	if err := json.Unmarshal(quoted, &s); err != nil {
+		return false, err
+	}
+	return s == key, nil
+}
+
+// Skip the whitespaces from data[i:], and return the index of the next character.
+func rawSkipSpaces(data []byte, i int) (int, error) {
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return i, nil
+		}
+	}
+	return 0, kindErrorf(ErrSyntax, "Unexpected end of json input\n")
+}
+
+// Skip the value at data[i:], and return the end of the value.
+func rawSkipValue(data []byte, i int) (int, error) {
+	switch c := data[i]; {
+	case c == '"':
+		return rawSkipString(data, i)
+	case c == '{' || c == '[':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				end, err := rawSkipString(data, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, kindErrorf(ErrSyntax, "Unexpected end of json input\n")
+	case c == '-' || (c >= '0' && c <= '9'):
+		end := i + 1
+		for end < len(data) && isRawNumberChar(data[end]) {
+			end++
+		}
+		return end, nil
+	default:
+		for _, lit := range []string{"true", "false", "null"} {
+			if len(data)-i >= len(lit) && string(data[i:i+len(lit)]) == lit {
+				return i + len(lit), nil
+			}
+		}
+		return 0, rawSyntaxError(data, i)
+	}
+}
+
+// Skip the string at data[i:], and return the end of the string (after the quote).
+func rawSkipString(data []byte, i int) (int, error) {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, kindErrorf(ErrSyntax, "Unexpected end of json input\n")
+}
+
+// Get the beginning of the value at data[start:] for error messages.
+func rawExcerpt(data []byte, start int) string {
+	end := start + 20
+	if end > len(data) {
+		end = len(data)
+	}
+	if end < len(data) {
+		return string(data[start:end]) + "..."
+	}
+	return string(data[start:end])
+}
+
+// Create a syntax error of the unexpected character at data[i].
+func rawSyntaxError(data []byte, i int) error {
+	return kindErrorf(ErrSyntax, "Invalid character '%c' at offset %d\n", data[i], i)
+}
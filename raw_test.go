@@ -0,0 +1,88 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+	"unsafe"
+)
+
+func TestSelectRaw(t *testing.T) {
+	bytes := *(*[]byte)(unsafe.Pointer(&arrDoc))
+	doc, err := NewJsonDocument(bytes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	for _, selector := range []string{"#0", "#1", "#2 a", "#2 b c", "#3 b f #0", "#3 b f #3", "#-2 b f #-1 #0", "#-1", "#1 b e", "#2 b e"} {
+		expected := handle(jq.SelectBySelector(selector))
+		res, err := SelectRawBySelector(bytes, selector)
+		xtesting.Equal(t, err, nil)
+		xtesting.Equal(t, handle(res.Value()), expected)
+	}
+
+	res, _ := SelectRaw(bytes, 3, "b", "c")
+	xtesting.Equal(t, string(res.Raw()), `"ddd"`)
+	xtesting.Equal(t, handle(res.String()), "ddd")
+	res, _ = SelectRaw(bytes, 3, "b", "e")
+	xtesting.Equal(t, handle(res.Float64()), 0.222)
+	xtesting.Equal(t, handle(res.Int64()), int64(0))
+	res, _ = SelectRaw(bytes, 3, "a")
+	xtesting.Equal(t, handle(res.Int64()), int64(2))
+	res, _ = SelectRaw(bytes, -1)
+	xtesting.Equal(t, res.IsNull(), true)
+	res, _ = SelectRaw([]byte(`{"ab": "cd", "t": true}`), "ab")
+	xtesting.Equal(t, handle(res.String()), "cd")
+	res, _ = SelectRaw([]byte(`{"ab": "cd", "t": true}`), "t")
+	xtesting.Equal(t, handle(res.Bool()), true)
+
+	_, err = res.String()
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = res.Int64()
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = SelectRaw(bytes, 9)
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = SelectRaw(bytes, -6)
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = SelectRaw(bytes, 1, "x")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = SelectRaw(bytes, "a")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = SelectRaw(bytes, 0, 0, 0)
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = SelectRaw(bytes, All())
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = SelectRaw([]byte(`{"a": [1, 2`), "a", 1)
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = SelectRaw([]byte(`{"a" 1}`), "a")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+}
+
+var benchSelector = MustCompile("c f #2 i")
+
+func BenchmarkSelectRaw(b *testing.B) {
+	bytes := []byte(objDoc)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res, err := SelectRawCompiled(bytes, benchSelector)
+		if err != nil || len(res.Raw()) == 0 {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSelect(b *testing.B) {
+	bytes := []byte(objDoc)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		doc, err := NewJsonDocument(bytes)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := NewJsonQuery(doc).SelectCompiled(benchSelector); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
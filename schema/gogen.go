@@ -0,0 +1,230 @@
+package schema
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Aoi-hosizora/jsonq"
+)
+
+// Options of generating Go types.
+type GoOptions struct {
+	Package   string // the package clause, omitted if empty
+	Name      string // the name of the root type, defaults to "Root"
+	JsonqTags bool   // add jsonq tags of the selectors from the root, such as `jsonq:"items * name"`
+}
+
+// Generate Go type definitions from sample documents, the samples are merged by Infer, see Type.GoTypes.
+func GenerateGo(options GoOptions, samples ...*jsonq.JsonDocument) ([]byte, error) {
+	typ, err := Infer(samples...)
+	if err != nil {
+		return nil, err
+	}
+	return typ.GoTypes(options)
+}
+
+// Generate formatted Go type definitions from the type, the root type comes first and the nested structs follow in the
+// order of the fields:
+//
+// 1. objects become structs, whose fields are named in CamelCase by their keys, with json tags (and jsonq tags);
+//
+// 2. booleans, integers, numbers and strings become bool, int64, float64 and string, arrays become slices, and the
+// empty objects, mixed types and unknown types become map[string]interface{}, interface{} and interface{};
+//
+// 3. the optional fields have "omitempty" in json tags, and they become pointers if they are scalars or structs, so are
+// the nullable fields;
+//
+// 4. the structs of the array elements are named with the "Item" suffix, and the names colliding with others are
+// prefixed by the parent names.
+//
+// 5. the keys which could not be names in json tags (such as "" and the keys with "," or "\"") are skipped with comments.
+func (t *Type) GoTypes(options GoOptions) ([]byte, error) {
+	g := &goGenerator{options: options, names: make(map[string]bool)}
+	name := options.Name
+	if name == "" {
+		name = "Root"
+	}
+	g.names[name] = true
+	root := "" // the underlying type if the root is not a struct
+	if len(t.Types) == 1 && t.Types[0] == "object" && len(t.Properties) > 0 {
+		g.generateStruct(t, name, "")
+	} else {
+		root = g.goType(t, name, "", "", false)
+	}
+
+	var parts []string
+	if options.Package != "" {
+		parts = append(parts, fmt.Sprintf("package %s\n", options.Package))
+	}
+	if root != "" {
+		parts = append(parts, fmt.Sprintf("type %s %s\n", name, root))
+	}
+	parts = append(parts, g.defs...)
+	return format.Source([]byte(strings.Join(parts, "\n")))
+}
+
+// A generator of Go types.
+type goGenerator struct {
+	options GoOptions
+	names   map[string]bool // used type names
+	defs    []string        // struct definitions in order
+}
+
+// Get the Go type of a type, the structs are generated with the name.
+func (g *goGenerator) goType(t *Type, name string, parent string, selector string, pointer bool) string {
+	var types []string
+	for _, typ := range t.Types {
+		if typ != "null" {
+			types = append(types, typ)
+		}
+	}
+	if len(types) != 1 {
+		return "interface{}"
+	}
+
+	out := ""
+	switch types[0] {
+	case "boolean":
+		out = "bool"
+	case "integer":
+		out = "int64"
+	case "number":
+		out = "float64"
+	case "string":
+		out = "string"
+	case "array":
+		if t.Items == nil {
+			return "[]interface{}"
+		}
+		return "[]" + g.goType(t.Items, name+"Item", parent, joinSelector(selector, "*"), false)
+	case "object":
+		if len(t.Properties) == 0 {
+			return "map[string]interface{}"
+		}
+		out = g.typeName(name, parent)
+		g.generateStruct(t, out, selector)
+	}
+	if pointer || t.Nullable() {
+		out = "*" + out
+	}
+	return out
+}
+
+// Generate a struct definition of an object type.
+func (g *goGenerator) generateStruct(t *Type, name string, selector string) {
+	keys := make([]string, 0, len(t.Properties))
+	for key := range t.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	required := make(map[string]bool, len(t.Required))
+	for _, key := range t.Required {
+		required[key] = true
+	}
+
+	idx := len(g.defs)
+	g.defs = append(g.defs, "") // reserve the position before the nested structs
+	fields := make(map[string]bool, len(keys))
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "type %s struct {\n", name)
+	for _, key := range keys {
+		if !isValidJsonTag(key) { // encoding/json ignores such a tag name and uses the field name instead
+			fmt.Fprintf(sb, "\t// %s is skipped, since it could not be a name in json tags\n", strconv.Quote(key))
+			continue
+		}
+		field := goName(key)
+		for i := 2; fields[field]; i++ {
+			field = goName(key) + strconv.Itoa(i)
+		}
+		fields[field] = true
+
+		sel := joinSelector(selector, jsonq.Escape(key))
+		typ := g.goType(t.Properties[key], goName(key), name, sel, !required[key])
+		tag := "json:" + strconv.Quote(key)
+		if !required[key] {
+			tag = "json:" + strconv.Quote(key+",omitempty")
+		} else if key == "-" {
+			tag = "json:" + strconv.Quote("-,") // a single "-" means to ignore the field
+		}
+		if g.options.JsonqTags {
+			tag += " jsonq:" + strconv.Quote(sel)
+		}
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(sb, "\t%s %s %s\n", field, typ, tag)
+	}
+	sb.WriteString("}\n")
+	g.defs[idx] = sb.String()
+}
+
+// Get an unused type name, the name colliding with others is prefixed by the parent name, or suffixed by a number.
+func (g *goGenerator) typeName(name string, parent string) string {
+	out := name
+	if g.names[out] {
+		out = parent + name
+	}
+	for i := 2; g.names[out]; i++ {
+		out = parent + name + strconv.Itoa(i)
+	}
+	g.names[out] = true
+	return out
+}
+
+// The initialisms kept in upper case in Go names.
+var goInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true, "sql": true,
+	"uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// Convert a json key to an exported Go name in CamelCase, such as "user_id" to "UserID".
+func goName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sb := &strings.Builder{}
+	for _, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			sb.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	out := sb.String()
+	if out == "" {
+		return "Field"
+	}
+	if r := []rune(out)[0]; !unicode.IsUpper(r) { // digits or letters without cases
+		return "X" + out
+	}
+	return out
+}
+
+// Check if the key could be a name in json tags, the same as the check in encoding/json.
+func isValidJsonTag(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", r) && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Append a layer to a selector.
+func joinSelector(selector string, layer string) string {
+	if selector == "" {
+		return layer
+	}
+	return selector + " " + layer
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	docs := mustDocuments(
+		`{"id": 1, "user_name": "a", "score": 1, "tags": ["x"], "owner": {"id": 1, "home_url": null}, "items": [{"n": 1}, {"n": 2, "#note": "x"}], "meta": {}}`,
+		`{"id": 2, "score": 1.5, "tags": [], "owner": {"id": 2, "home_url": "u"}, "items": [], "any": [1, "a"], "2fa": true}`,
+	)
+	code, err := GenerateGo(GoOptions{Package: "model"}, docs...)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, string(code), "package model\n\n"+
+		"type Root struct {\n"+
+		"\tX2fa     *bool                  `json:\"2fa,omitempty\"`\n"+
+		"\tAny      []interface{}          `json:\"any,omitempty\"`\n"+
+		"\tID       int64                  `json:\"id\"`\n"+
+		"\tItems    []ItemsItem            `json:\"items\"`\n"+
+		"\tMeta     map[string]interface{} `json:\"meta,omitempty\"`\n"+
+		"\tOwner    Owner                  `json:\"owner\"`\n"+
+		"\tScore    float64                `json:\"score\"`\n"+
+		"\tTags     []string               `json:\"tags\"`\n"+
+		"\tUserName *string                `json:\"user_name,omitempty\"`\n"+
+		"}\n\n"+
+		"type ItemsItem struct {\n"+
+		"\tNote *string `json:\"#note,omitempty\"`\n"+
+		"\tN    int64   `json:\"n\"`\n"+
+		"}\n\n"+
+		"type Owner struct {\n"+
+		"\tHomeURL *string `json:\"home_url\"`\n"+
+		"\tID      int64   `json:\"id\"`\n"+
+		"}\n")
+
+	docs = mustDocuments(`[{"a": {"b": 1}, "c": [{"a": {"d": "x"}}]}]`)
+	code, err = GenerateGo(GoOptions{Name: "Feed", JsonqTags: true}, docs...)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, string(code), "type Feed []FeedItem\n\n"+
+		"type FeedItem struct {\n"+
+		"\tA A       `json:\"a\" jsonq:\"* a\"`\n"+
+		"\tC []CItem `json:\"c\" jsonq:\"* c\"`\n"+
+		"}\n\n"+
+		"type A struct {\n"+
+		"\tB int64 `json:\"b\" jsonq:\"* a b\"`\n"+
+		"}\n\n"+
+		"type CItem struct {\n"+
+		"\tA CItemA `json:\"a\" jsonq:\"* c * a\"`\n"+
+		"}\n\n"+
+		"type CItemA struct {\n"+
+		"\tD string `json:\"d\" jsonq:\"* c * a d\"`\n"+
+		"}\n")
+
+	// the keys which could not be names in json tags
+	docs = mustDocuments(`{"": 1, "a,b": 2, "-": 3, "c": 4}`)
+	code, err = GenerateGo(GoOptions{}, docs...)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, string(code), "type Root struct {\n"+
+		"\t// \"\" is skipped, since it could not be a name in json tags\n"+
+		"\tField int64 `json:\"-,\"`\n"+
+		"\t// \"a,b\" is skipped, since it could not be a name in json tags\n"+
+		"\tC int64 `json:\"c\"`\n"+
+		"}\n")
+
+	xtesting.Equal(t, goName("user_id"), "UserID")
+	xtesting.Equal(t, goName("createdAt"), "CreatedAt")
+	xtesting.Equal(t, goName("--"), "Field")
+	xtesting.Equal(t, goName("été"), "Été")
+	xtesting.Equal(t, goName("名前"), "X名前")
+}
@@ -0,0 +1,267 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Aoi-hosizora/jsonq"
+)
+
+// A type inferred from sample documents, which could be converted to a JSON Schema by Schema, or to a compact summary
+// by String, such as:
+//
+//	{
+//	  id: integer
+//	  name?: string | null
+//	  status: "active" | "closed"
+//	  tags: []string
+//	}
+type Type struct {
+	Types      []string         // JSON Schema type names in the order of typeOrder, "null" means the value is nullable
+	Enum       []string         // sorted values of a small set of strings, see InferOptions.MaxEnumValues
+	Properties map[string]*Type // types of the object properties
+	Required   []string         // sorted properties present in all the objects
+	Items      *Type            // merged type of the array elements, nil if all the arrays are empty
+	Count      int              // count of the observed values
+}
+
+// The order of the type names in Type.Types, the integers are merged into the numbers if both are observed.
+var typeOrder = []string{"boolean", "integer", "number", "string", "array", "object", "null"}
+
+// The default max count of enum values.
+const defaultMaxEnumValues = 5
+
+// Options of inferring types.
+type InferOptions struct {
+	// The max count of the distinct strings to be inferred as an enum, defaults to 5, and a negative value disables
+	// enums. The strings are inferred as an enum only if some of them are repeated, so that unique values such as names
+	// and ids are not.
+	MaxEnumValues int
+}
+
+// Infer a type from sample documents by the default options, see InferWithOptions.
+func Infer(samples ...*jsonq.JsonDocument) (*Type, error) {
+	return InferWithOptions(InferOptions{}, samples...)
+}
+
+// Infer a type from sample documents, the types of the same path are merged across the samples. The properties missing
+// in some objects are optional, the values observed as null are nullable, and the elements of all the arrays in the
+// same path are merged into a single item type.
+func InferWithOptions(options InferOptions, samples ...*jsonq.JsonDocument) (*Type, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("Expected at least one sample document\n")
+	}
+	if options.MaxEnumValues == 0 {
+		options.MaxEnumValues = defaultMaxEnumValues
+	}
+	root := newInferNode()
+	for _, doc := range samples {
+		val, err := jsonq.NewJsonQuery(doc).SelectByJsonPointer("")
+		if err != nil {
+			return nil, err
+		}
+		root.observe(val, options.MaxEnumValues)
+	}
+	return root.build(options.MaxEnumValues), nil
+}
+
+// A node of the observed values in the same path.
+type inferNode struct {
+	count   int
+	types   map[string]bool
+	strings map[string]bool // distinct strings, stop collecting when exceeding the max enum count
+	strs    int             // count of the strings
+	objects int
+	props   map[string]*inferNode
+	items   *inferNode
+}
+
+func newInferNode() *inferNode {
+	return &inferNode{types: make(map[string]bool), strings: make(map[string]bool), props: make(map[string]*inferNode)}
+}
+
+// Merge an observed value into the node.
+func (n *inferNode) observe(val interface{}, maxEnum int) {
+	n.count++
+	n.types[typeName(val)] = true
+	switch val := val.(type) {
+	case string:
+		n.strs++
+		if len(n.strings) <= maxEnum {
+			n.strings[val] = true
+		}
+	case []interface{}:
+		for _, item := range val {
+			if n.items == nil {
+				n.items = newInferNode()
+			}
+			n.items.observe(item, maxEnum)
+		}
+	case map[string]interface{}:
+		n.objects++
+		for key, prop := range val {
+			if n.props[key] == nil {
+				n.props[key] = newInferNode()
+			}
+			n.props[key].observe(prop, maxEnum)
+		}
+	}
+}
+
+// Build the Type from the observed values.
+func (n *inferNode) build(maxEnum int) *Type {
+	t := &Type{Count: n.count}
+	for _, typ := range typeOrder {
+		if n.types[typ] && !(typ == "integer" && n.types["number"]) {
+			t.Types = append(t.Types, typ)
+		}
+	}
+	if t.isEnum() && n.strs > len(n.strings) && len(n.strings) <= maxEnum {
+		for str := range n.strings {
+			t.Enum = append(t.Enum, str)
+		}
+		sort.Strings(t.Enum)
+	}
+	if n.types["object"] {
+		t.Properties = make(map[string]*Type, len(n.props))
+		for key, prop := range n.props {
+			t.Properties[key] = prop.build(maxEnum)
+			if prop.count == n.objects {
+				t.Required = append(t.Required, key)
+			}
+		}
+		sort.Strings(t.Required)
+	}
+	if n.items != nil {
+		t.Items = n.items.build(maxEnum)
+	}
+	return t
+}
+
+// Check if the type is nullable.
+func (t *Type) Nullable() bool {
+	return len(t.Types) > 0 && t.Types[len(t.Types)-1] == "null"
+}
+
+// Convert the type to a JSON Schema (draft 2020-12) in indented json, which could be compiled by Compile.
+func (t *Type) Schema() ([]byte, error) {
+	sch := t.schema()
+	sch["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return json.MarshalIndent(sch, "", "  ")
+}
+
+func (t *Type) schema() map[string]interface{} {
+	sch := make(map[string]interface{})
+	switch len(t.Types) {
+	case 0:
+	case 1:
+		sch["type"] = t.Types[0]
+	default:
+		sch["type"] = t.Types
+	}
+	if len(t.Enum) > 0 {
+		enum := make([]interface{}, 0, len(t.Enum)+1)
+		for _, str := range t.Enum {
+			enum = append(enum, str)
+		}
+		if t.Nullable() {
+			enum = append(enum, nil)
+		}
+		sch["enum"] = enum
+	}
+	if t.Properties != nil {
+		props := make(map[string]interface{}, len(t.Properties))
+		for key, prop := range t.Properties {
+			props[key] = prop.schema()
+		}
+		sch["properties"] = props
+		if len(t.Required) > 0 {
+			sch["required"] = t.Required
+		}
+	}
+	if t.Items != nil {
+		sch["items"] = t.Items.schema()
+	}
+	return sch
+}
+
+// Check if the type could be an enum, that is, the values are only strings and nulls.
+func (t *Type) isEnum() bool {
+	return len(t.Types) == 1 && t.Types[0] == "string" || len(t.Types) == 2 && t.Types[0] == "string" && t.Nullable()
+}
+
+// Get the compact summary of the type, see Type.
+func (t *Type) String() string {
+	sb := &strings.Builder{}
+	t.summary(sb, "")
+	return sb.String()
+}
+
+// Write the summary in an indentation.
+func (t *Type) summary(sb *strings.Builder, indent string) {
+	if len(t.Types) == 0 {
+		sb.WriteString("unknown")
+		return
+	}
+	parts := make([]string, 0, len(t.Types))
+	for _, typ := range t.Types {
+		switch {
+		case typ == "string" && len(t.Enum) > 0:
+			for _, str := range t.Enum {
+				parts = append(parts, strconv.Quote(str))
+			}
+		case typ == "array" || typ == "object":
+			child := &strings.Builder{}
+			if typ == "array" {
+				child.WriteString("[]")
+				if t.Items == nil {
+					child.WriteString("unknown")
+				} else if len(t.Items.Types) > 1 || len(t.Items.Enum) > 1 {
+					child.WriteString("(")
+					t.Items.summary(child, indent)
+					child.WriteString(")")
+				} else {
+					t.Items.summary(child, indent)
+				}
+			} else {
+				t.objectSummary(child, indent)
+			}
+			parts = append(parts, child.String())
+		default:
+			parts = append(parts, typ)
+		}
+	}
+	sb.WriteString(strings.Join(parts, " | "))
+}
+
+// Write the summary of the object properties, the optional properties are marked by "?".
+func (t *Type) objectSummary(sb *strings.Builder, indent string) {
+	if len(t.Properties) == 0 {
+		sb.WriteString("{}")
+		return
+	}
+	keys := make([]string, 0, len(t.Properties))
+	for key := range t.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	required := make(map[string]bool, len(t.Required))
+	for _, key := range t.Required {
+		required[key] = true
+	}
+
+	sb.WriteString("{\n")
+	for _, key := range keys {
+		sb.WriteString(indent + "  " + jsonq.Escape(key))
+		if !required[key] {
+			sb.WriteString("?")
+		}
+		sb.WriteString(": ")
+		t.Properties[key].summary(sb, indent+"  ")
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + "}")
+}
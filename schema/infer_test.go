@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"log"
+	"testing"
+)
+
+func mustDocuments(samples ...string) []*jsonq.JsonDocument {
+	docs := make([]*jsonq.JsonDocument, len(samples))
+	for idx, sample := range samples {
+		doc, err := jsonq.NewJsonDocument([]byte(sample))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		docs[idx] = doc
+	}
+	return docs
+}
+
+func TestInfer(t *testing.T) {
+	docs := mustDocuments(
+		`{"id": 1, "name": "a", "status": "active", "score": 1, "tags": ["x"], "owner": {"id": 1, "email": null}, "items": [{"n": 1}, {"n": 2, "note": "x"}]}`,
+		`{"id": 2, "name": null, "status": "closed", "score": 1.5, "tags": [], "owner": {"id": 2, "email": "b@c"}, "items": []}`,
+		`{"id": 3, "status": "active", "score": 2, "tags": ["y", 1], "owner": {"id": 3}, "misc": [[]]}`,
+	)
+	typ, err := Infer(docs...)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, typ.Types, []string{"object"})
+	xtesting.Equal(t, typ.Count, 3)
+	xtesting.Equal(t, typ.Required, []string{"id", "owner", "score", "status", "tags"})
+	xtesting.Equal(t, typ.Properties["id"].Types, []string{"integer"})
+	xtesting.Equal(t, typ.Properties["score"].Types, []string{"number"})
+	xtesting.Equal(t, typ.Properties["name"].Types, []string{"string", "null"})
+	xtesting.Equal(t, typ.Properties["name"].Nullable(), true)
+	xtesting.Equal(t, typ.Properties["name"].Enum, []string(nil))
+	xtesting.Equal(t, typ.Properties["status"].Enum, []string{"active", "closed"})
+	xtesting.Equal(t, typ.Properties["tags"].Items.Types, []string{"integer", "string"})
+	xtesting.Equal(t, typ.Properties["owner"].Required, []string{"id"})
+	xtesting.Equal(t, typ.Properties["items"].Items.Count, 2)
+	xtesting.Equal(t, typ.Properties["items"].Items.Required, []string{"n"})
+	xtesting.Equal(t, typ.Properties["misc"].Items.Items, (*Type)(nil))
+
+	xtesting.Equal(t, typ.String(), `{
+  id: integer
+  items?: []{
+    n: integer
+    note?: string
+  }
+  misc?: [][]unknown
+  name?: string | null
+  owner: {
+    email?: string | null
+    id: integer
+  }
+  score: number
+  status: "active" | "closed"
+  tags: [](integer | string)
+}`)
+
+	// the inferred schema validates the samples
+	data, err := typ.Schema()
+	xtesting.Equal(t, err, nil)
+	s, err := Compile(data)
+	xtesting.Equal(t, err, nil)
+	for _, doc := range docs {
+		xtesting.Equal(t, s.Validate(doc), nil)
+	}
+	doc := mustDocuments(`{"id": 1.5, "status": "open", "score": 1, "tags": [], "owner": {}}`)[0]
+	xtesting.Equal(t, violations(s.Validate(doc)), []string{"id | /properties/id/type", "owner | /properties/owner/required", "status | /properties/status/enum"})
+}
+
+func TestInferOptions(t *testing.T) {
+	docs := mustDocuments(`["a", "b", "a", null]`, `["c", "c"]`)
+	typ, _ := Infer(docs...)
+	xtesting.Equal(t, typ.String(), `[]("a" | "b" | "c" | null)`)
+	data, _ := typ.Schema()
+	xtesting.Equal(t, string(data), `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "items": {
+    "enum": [
+      "a",
+      "b",
+      "c",
+      null
+    ],
+    "type": [
+      "string",
+      "null"
+    ]
+  },
+  "type": "array"
+}`)
+
+	typ, _ = InferWithOptions(InferOptions{MaxEnumValues: 2}, docs...)
+	xtesting.Equal(t, typ.String(), `[](string | null)`)
+	typ, _ = InferWithOptions(InferOptions{MaxEnumValues: -1}, docs...)
+	xtesting.Equal(t, typ.Items.Enum, []string(nil))
+	typ, _ = Infer(mustDocuments(`["a", "b"]`)...)
+	xtesting.Equal(t, typ.String(), `[]string`)
+	typ, _ = Infer(mustDocuments(`[{}]`, `[]`)...)
+	xtesting.Equal(t, typ.String(), `[]{}`)
+
+	_, err := Infer()
+	xtesting.NotEqual(t, err, nil)
+}
@@ -0,0 +1,216 @@
+// Package schema validates json documents of jsonq against JSON Schema (draft 2020-12).
+//
+// Only the local references are supported, such as "#", "#/$defs/item" and "#item" (by "$anchor"), and a reference
+// prefixed by the root "$id" is also treated as local. "$dynamicRef" is resolved like "$ref", because a schema has only
+// one resource. "format" is an annotation and is not asserted, as the default behavior of draft 2020-12.
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Aoi-hosizora/jsonq"
+)
+
+// A compiled JSON Schema, it is safe to be used concurrently.
+type Schema struct {
+	root     interface{}               // bool or map[string]interface{}
+	doc      *jsonq.JsonDocument       // nil if the root is bool
+	id       string                    // "$id" of the root, without the fragment
+	anchors  map[string]interface{}    // "$anchor" and "$dynamicAnchor" -> subschema
+	patterns map[string]*regexp.Regexp // "pattern" and "patternProperties" -> regexp
+}
+
+// The keywords whose values are a single subschema.
+var schemaKeywords = []string{
+	"additionalProperties", "propertyNames", "items", "contains", "not", "if", "then", "else",
+	"unevaluatedItems", "unevaluatedProperties",
+}
+
+// The keywords whose values are arrays of subschemas.
+var schemaArrayKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+
+// The keywords whose values are objects of subschemas.
+var schemaObjectKeywords = []string{"properties", "patternProperties", "dependentSchemas", "$defs", "definitions"}
+
+// Compile a JSON Schema from json, the root could be an object or a boolean.
+func Compile(data []byte) (*Schema, error) {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		return &Schema{root: true}, nil
+	case "false":
+		return &Schema{root: false}, nil
+	}
+	doc, err := jsonq.NewJsonDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return CompileDocument(doc)
+}
+
+// Compile a JSON Schema from a JsonDocument, the root must be an object.
+func CompileDocument(doc *jsonq.JsonDocument) (*Schema, error) {
+	root, err := jsonq.NewJsonQuery(doc).SelectByJsonPointer("")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := root.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("Expected an object or a boolean as the schema, got %T\n", root)
+	}
+
+	s := &Schema{root: root, doc: doc, anchors: make(map[string]interface{}), patterns: make(map[string]*regexp.Regexp)}
+	if id, ok := root.(map[string]interface{})["$id"].(string); ok {
+		s.id = strings.SplitN(id, "#", 2)[0]
+	}
+	refs := make(map[string]string) // ref -> location
+	if err := s.compile(root, "", refs); err != nil {
+		return nil, err
+	}
+
+	locations := make([]string, 0, len(refs))
+	for ref := range refs {
+		locations = append(locations, ref)
+	}
+	sort.Strings(locations)
+	for _, ref := range locations {
+		if _, err := s.resolve(ref); err != nil {
+			return nil, fmt.Errorf("Invalid schema at \"%s\": %v", refs[ref], err)
+		}
+	}
+	return s, nil
+}
+
+// Check a subschema recursively, collect its anchors, patterns and references.
+func (s *Schema) compile(sch interface{}, location string, refs map[string]string) error {
+	if _, ok := sch.(bool); ok {
+		return nil
+	}
+	obj, ok := sch.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Invalid schema at \"%s\": expected an object or a boolean, got %T\n", location, sch)
+	}
+
+	names := make(map[string]bool, 2) // "$anchor" and "$dynamicAnchor" may have the same name
+	for _, keyword := range []string{"$anchor", "$dynamicAnchor"} {
+		if anchor, ok := obj[keyword]; ok {
+			name, ok := anchor.(string)
+			if !ok || name == "" {
+				return fmt.Errorf("Invalid schema at \"%s\": expected a non-empty string\n", location+"/"+keyword)
+			}
+			if _, ok := s.anchors[name]; ok && !names[name] {
+				return fmt.Errorf("Invalid schema at \"%s\": duplicate anchor \"%s\"\n", location+"/"+keyword, name)
+			}
+			s.anchors[name] = sch
+			names[name] = true
+		}
+	}
+	for _, keyword := range []string{"$ref", "$dynamicRef"} {
+		if ref, ok := obj[keyword]; ok {
+			str, ok := ref.(string)
+			if !ok {
+				return fmt.Errorf("Invalid schema at \"%s\": expected a string\n", location+"/"+keyword)
+			}
+			refs[str] = location + "/" + keyword
+		}
+	}
+	if pattern, ok := obj["pattern"]; ok {
+		if err := s.compilePattern(pattern, location+"/pattern"); err != nil {
+			return err
+		}
+	}
+	if props, ok := obj["patternProperties"].(map[string]interface{}); ok {
+		for pattern := range props {
+			if err := s.compilePattern(pattern, location+"/patternProperties/"+escapePointer(pattern)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range schemaKeywords {
+		if sub, ok := obj[keyword]; ok {
+			if err := s.compile(sub, location+"/"+keyword, refs); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range schemaArrayKeywords {
+		if val, ok := obj[keyword]; ok {
+			arr, ok := val.([]interface{})
+			if !ok {
+				return fmt.Errorf("Invalid schema at \"%s\": expected an array, got %T\n", location+"/"+keyword, val)
+			}
+			for idx, sub := range arr {
+				if err := s.compile(sub, location+"/"+keyword+"/"+strconv.Itoa(idx), refs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range schemaObjectKeywords {
+		if val, ok := obj[keyword]; ok {
+			subs, ok := val.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Invalid schema at \"%s\": expected an object, got %T\n", location+"/"+keyword, val)
+			}
+			for key, sub := range subs {
+				if err := s.compile(sub, location+"/"+keyword+"/"+escapePointer(key), refs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Compile a regular expression in the schema.
+func (s *Schema) compilePattern(pattern interface{}, location string) error {
+	str, ok := pattern.(string)
+	if !ok {
+		return fmt.Errorf("Invalid schema at \"%s\": expected a string, got %T\n", location, pattern)
+	}
+	re, err := regexp.Compile(str)
+	if err != nil {
+		return fmt.Errorf("Invalid schema at \"%s\": %v\n", location, err)
+	}
+	s.patterns[str] = re
+	return nil
+}
+
+// Resolve a local reference to a subschema.
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if s.id != "" && strings.HasPrefix(ref, s.id) {
+		ref = ref[len(s.id):]
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local references are supported, got \"%s\"\n", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference \"%s\": %v\n", ref, err)
+	}
+	if fragment != "" && fragment[0] != '/' {
+		sch, ok := s.anchors[fragment]
+		if !ok {
+			return nil, fmt.Errorf("anchor of reference \"%s\" is not found\n", ref)
+		}
+		return sch, nil
+	}
+	if s.doc == nil {
+		return nil, fmt.Errorf("reference \"%s\" is not found\n", ref)
+	}
+	sch, err := jsonq.NewJsonQuery(s.doc).SelectByJsonPointer(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("reference \"%s\" is not found\n", ref)
+	}
+	return sch, nil
+}
+
+// Escape a reference token of json pointer.
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
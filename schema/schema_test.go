@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"log"
+	"strings"
+	"testing"
+)
+
+func mustCompile(data string) *Schema {
+	s, err := Compile([]byte(data))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return s
+}
+
+func TestCompile(t *testing.T) {
+	s := mustCompile(`true`)
+	xtesting.Equal(t, s.ValidateValue(1.), nil)
+	s = mustCompile(` false `)
+	xtesting.NotEqual(t, s.ValidateValue(1.), nil)
+
+	s = mustCompile(`{
+		"$id": "https://example.com/item.json",
+		"$defs": {
+			"a/b": {"type": "string"},
+			"c": {"$anchor": "c", "$dynamicAnchor": "c", "type": "integer"},
+			"d%e": {"type": "null"}
+		},
+		"prefixItems": [
+			{"$ref": "#/$defs/a~1b"},
+			{"$ref": "#c"},
+			{"$ref": "https://example.com/item.json#/$defs/d%25e"},
+			{"$dynamicRef": "#c"}
+		]
+	}`)
+	xtesting.Equal(t, s.ValidateValue([]interface{}{"x", 1., nil, 2.}), nil)
+	xtesting.NotEqual(t, s.ValidateValue([]interface{}{1., "x", 0., 0.5}), nil)
+
+	for _, data := range []string{
+		``,
+		`1`,
+		`[]`,
+		`{"$ref": "other.json#/a"}`,
+		`{"$ref": "#/$defs/x"}`,
+		`{"$ref": "#x"}`,
+		`{"$ref": 1}`,
+		`{"pattern": "("}`,
+		`{"patternProperties": {"[": {}}}`,
+		`{"properties": {"a": 1}}`,
+		`{"allOf": {}}`,
+		`{"$defs": {"a": {"$anchor": "x"}, "b": {"$anchor": "x"}}}`,
+	} {
+		_, err := Compile([]byte(data))
+		xtesting.NotEqual(t, err, nil)
+	}
+	_, err := Compile([]byte(`{"a": }`))
+	xtesting.Equal(t, errors.Is(err, jsonq.ErrSyntax), true)
+	_, err = Compile([]byte(`{"properties": {"a": {"items": {"$ref": "#/$defs/x"}}}}`))
+	xtesting.Equal(t, strings.HasPrefix(err.Error(), "Invalid schema at \"/properties/a/items/$ref\""), true)
+
+	doc, _ := jsonq.NewJsonDocumentWithOptions([]byte(`{type: 'object', /* comment */ required: ['a']}`), jsonq.ParseOptions{Syntax: jsonq.SyntaxJson5})
+	s, err = CompileDocument(doc)
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, s.ValidateValue(map[string]interface{}{"a": 1.}), nil)
+	doc, _ = jsonq.NewJsonDocument([]byte(`[]`))
+	_, err = CompileDocument(doc)
+	xtesting.NotEqual(t, err, nil)
+}
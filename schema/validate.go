@@ -0,0 +1,538 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Aoi-hosizora/jsonq"
+)
+
+// A violation of the schema.
+type ValidationError struct {
+	Path    string // jsonq selector of the invalid value, such as "c f #0 g", an empty string means the root
+	Keyword string // json pointer of the failed keyword along the evaluation, such as "/properties/c/$ref/type"
+	Msg     string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s at the root\n", e.Msg)
+	}
+	return fmt.Sprintf("%s at \"%s\"\n", e.Msg, e.Path)
+}
+
+// All violations of the schema, in the order of evaluation.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	sb := &strings.Builder{}
+	for _, err := range e {
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Validate a whole document, the error is ValidationErrors if the document is invalid.
+func (s *Schema) Validate(doc *jsonq.JsonDocument) error {
+	val, err := jsonq.NewJsonQuery(doc).SelectByJsonPointer("")
+	if err != nil {
+		return err
+	}
+	return s.validateAt(val, "")
+}
+
+// Validate a subtree selected by a selector string, the paths in ValidationErrors are prefixed by the selector. If the
+// selector selects multiple values (by "*", "+", patterns or slices), they are validated as an array.
+func (s *Schema) ValidateBySelector(doc *jsonq.JsonDocument, selectorString string) error {
+	val, err := jsonq.NewJsonQuery(doc).SelectBySelector(selectorString)
+	if err != nil {
+		return err
+	}
+	return s.validateAt(val, strings.TrimSpace(selectorString))
+}
+
+// Validate a value in the blob model of jsonq, such as the result of JsonQuery.Select.
+func (s *Schema) ValidateValue(val interface{}) error {
+	return s.validateAt(val, "")
+}
+
+func (s *Schema) validateAt(val interface{}, path string) error {
+	v := &validator{schema: s, active: make(map[string]bool)}
+	sc := &scope{path: path, evaluated: newEvaluated()}
+	v.validate(s.root, val, sc)
+	if len(sc.errs) > 0 {
+		return sc.errs
+	}
+	return nil
+}
+
+// A validator of a single validation.
+type validator struct {
+	schema *Schema
+	active map[string]bool // references being evaluated and their instance paths, to stop infinite recursions
+}
+
+// Annotations of the evaluated properties and items, used by "unevaluatedProperties" and "unevaluatedItems".
+type evaluated struct {
+	props    map[string]bool
+	items    map[int]bool
+	allItems bool
+}
+
+func newEvaluated() *evaluated {
+	return &evaluated{props: make(map[string]bool), items: make(map[int]bool)}
+}
+
+func (e *evaluated) merge(o *evaluated) {
+	for key := range o.props {
+		e.props[key] = true
+	}
+	for idx := range o.items {
+		e.items[idx] = true
+	}
+	e.allItems = e.allItems || o.allItems
+}
+
+// A scope of validating an instance by a subschema.
+type scope struct {
+	path      string // jsonq selector of the instance
+	keyword   string // json pointer of the subschema along the evaluation
+	errs      ValidationErrors
+	evaluated *evaluated
+}
+
+// Report a violation of a keyword in the scope.
+func (c *scope) fail(keyword string, format string, v ...interface{}) {
+	c.errs = append(c.errs, &ValidationError{Path: c.path, Keyword: c.keyword + "/" + keyword, Msg: fmt.Sprintf(format, v...)})
+}
+
+// Create a scope of the same instance, for in-place applicators such as "allOf" and "$ref".
+func (c *scope) inPlace(keyword string) *scope {
+	return &scope{path: c.path, keyword: c.keyword + "/" + keyword, evaluated: newEvaluated()}
+}
+
+// Create a scope of a child instance, such as a property or an item.
+func (c *scope) child(segment string, keyword string) *scope {
+	path := segment
+	if c.path != "" {
+		path = c.path + " " + segment
+	}
+	return &scope{path: path, keyword: c.keyword + "/" + keyword, evaluated: newEvaluated()}
+}
+
+// Validate an instance by a subschema, the violations and annotations are collected into the scope.
+func (v *validator) validate(sch interface{}, inst interface{}, sc *scope) {
+	obj, ok := sch.(map[string]interface{})
+	if !ok {
+		if sch == false {
+			sc.errs = append(sc.errs, &ValidationError{Path: sc.path, Keyword: sc.keyword, Msg: "Value is not allowed by the false schema"})
+		}
+		return
+	}
+
+	v.validateInPlace(obj, inst, sc)
+	v.validateGeneric(obj, inst, sc)
+	switch inst := inst.(type) {
+	case string:
+		v.validateString(obj, inst, sc)
+	case []interface{}:
+		v.validateArray(obj, inst, sc)
+	case map[string]interface{}:
+		v.validateObject(obj, inst, sc)
+	default:
+		if num, ok := toFloat(inst); ok {
+			v.validateNumber(obj, num, sc)
+		}
+	}
+}
+
+// Validate the subschema in another scope, and returns true if it is valid.
+func (v *validator) validateIn(sch interface{}, inst interface{}, sc *scope) bool {
+	v.validate(sch, inst, sc)
+	return len(sc.errs) == 0
+}
+
+// Validate "$ref", "$dynamicRef", "allOf", "anyOf", "oneOf", "not", "if", "then", "else" and "dependentSchemas".
+func (v *validator) validateInPlace(obj map[string]interface{}, inst interface{}, sc *scope) {
+	for _, keyword := range []string{"$ref", "$dynamicRef"} {
+		ref, ok := obj[keyword].(string)
+		if !ok {
+			continue
+		}
+		key := ref + "\x00" + sc.path
+		if v.active[key] {
+			sc.fail(keyword, "Reference \"%s\" recurses infinitely", ref)
+			continue
+		}
+		target, _ := v.schema.resolve(ref) // checked in compiling
+		v.active[key] = true
+		sub := sc.inPlace(keyword)
+		v.validate(target, inst, sub)
+		delete(v.active, key)
+		sc.errs = append(sc.errs, sub.errs...)
+		sc.evaluated.merge(sub.evaluated)
+	}
+
+	if subs, ok := obj["allOf"].([]interface{}); ok {
+		for idx, sch := range subs {
+			sub := sc.inPlace("allOf/" + strconv.Itoa(idx))
+			v.validate(sch, inst, sub)
+			sc.errs = append(sc.errs, sub.errs...)
+			sc.evaluated.merge(sub.evaluated)
+		}
+	}
+	if subs, ok := obj["anyOf"].([]interface{}); ok {
+		matched := 0
+		for idx, sch := range subs {
+			sub := sc.inPlace("anyOf/" + strconv.Itoa(idx))
+			if v.validateIn(sch, inst, sub) {
+				matched++
+				sc.evaluated.merge(sub.evaluated)
+			}
+		}
+		if matched == 0 {
+			sc.fail("anyOf", "Value does not match any schema in anyOf")
+		}
+	}
+	if subs, ok := obj["oneOf"].([]interface{}); ok {
+		matched := 0
+		for idx, sch := range subs {
+			sub := sc.inPlace("oneOf/" + strconv.Itoa(idx))
+			if v.validateIn(sch, inst, sub) {
+				matched++
+				sc.evaluated.merge(sub.evaluated)
+			}
+		}
+		if matched != 1 {
+			sc.fail("oneOf", "Value matches %d schemas in oneOf, expected exactly one", matched)
+		}
+	}
+	if sch, ok := obj["not"]; ok {
+		if v.validateIn(sch, inst, sc.inPlace("not")) {
+			sc.fail("not", "Value should not match the schema in not")
+		}
+	}
+
+	if sch, ok := obj["if"]; ok {
+		cond := sc.inPlace("if")
+		branch := "else"
+		if v.validateIn(sch, inst, cond) {
+			branch = "then"
+			sc.evaluated.merge(cond.evaluated)
+		}
+		if sch, ok := obj[branch]; ok {
+			sub := sc.inPlace(branch)
+			v.validate(sch, inst, sub)
+			sc.errs = append(sc.errs, sub.errs...)
+			sc.evaluated.merge(sub.evaluated)
+		}
+	}
+
+	if deps, ok := obj["dependentSchemas"].(map[string]interface{}); ok {
+		if inst, ok := inst.(map[string]interface{}); ok {
+			for _, key := range sortedKeys(deps) {
+				if _, ok := inst[key]; ok {
+					sub := sc.inPlace("dependentSchemas/" + escapePointer(key))
+					v.validate(deps[key], inst, sub)
+					sc.errs = append(sc.errs, sub.errs...)
+					sc.evaluated.merge(sub.evaluated)
+				}
+			}
+		}
+	}
+}
+
+// Validate "type", "enum" and "const".
+func (v *validator) validateGeneric(obj map[string]interface{}, inst interface{}, sc *scope) {
+	if typ, ok := obj["type"]; ok {
+		var types []string
+		switch typ := typ.(type) {
+		case string:
+			types = []string{typ}
+		case []interface{}:
+			for _, t := range typ {
+				if t, ok := t.(string); ok {
+					types = append(types, t)
+				}
+			}
+		}
+		matched := false
+		for _, t := range types {
+			if matchType(t, inst) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sc.fail("type", "Expected type %s, got %s", strings.Join(types, " or "), typeName(inst))
+		}
+	}
+
+	if enum, ok := obj["enum"].([]interface{}); ok {
+		matched := false
+		for _, val := range enum {
+			if jsonq.Equal(val, inst) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sc.fail("enum", "Value is not one of the enum values")
+		}
+	}
+	if val, ok := obj["const"]; ok && !jsonq.Equal(val, inst) {
+		sc.fail("const", "Value is not equal to the const value")
+	}
+}
+
+// Validate "multipleOf", "maximum", "exclusiveMaximum", "minimum" and "exclusiveMinimum".
+func (v *validator) validateNumber(obj map[string]interface{}, num float64, sc *scope) {
+	if m, ok := toFloat(obj["multipleOf"]); ok && m > 0 {
+		q := num / m
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9 {
+			sc.fail("multipleOf", "Number %s is not a multiple of %s", formatNumber(num), formatNumber(m))
+		}
+	}
+	if max, ok := toFloat(obj["maximum"]); ok && num > max {
+		sc.fail("maximum", "Number %s is greater than the maximum %s", formatNumber(num), formatNumber(max))
+	}
+	if max, ok := toFloat(obj["exclusiveMaximum"]); ok && num >= max {
+		sc.fail("exclusiveMaximum", "Number %s is not less than the exclusive maximum %s", formatNumber(num), formatNumber(max))
+	}
+	if min, ok := toFloat(obj["minimum"]); ok && num < min {
+		sc.fail("minimum", "Number %s is less than the minimum %s", formatNumber(num), formatNumber(min))
+	}
+	if min, ok := toFloat(obj["exclusiveMinimum"]); ok && num <= min {
+		sc.fail("exclusiveMinimum", "Number %s is not greater than the exclusive minimum %s", formatNumber(num), formatNumber(min))
+	}
+}
+
+// Validate "maxLength", "minLength" and "pattern".
+func (v *validator) validateString(obj map[string]interface{}, str string, sc *scope) {
+	length := utf8.RuneCountInString(str)
+	if max, ok := toFloat(obj["maxLength"]); ok && float64(length) > max {
+		sc.fail("maxLength", "String length %d is greater than the max length %s", length, formatNumber(max))
+	}
+	if min, ok := toFloat(obj["minLength"]); ok && float64(length) < min {
+		sc.fail("minLength", "String length %d is less than the min length %s", length, formatNumber(min))
+	}
+	if pattern, ok := obj["pattern"].(string); ok && !v.schema.patterns[pattern].MatchString(str) {
+		sc.fail("pattern", "String \"%s\" does not match the pattern \"%s\"", str, pattern)
+	}
+}
+
+// Validate "prefixItems", "items", "contains", "maxContains", "minContains", "maxItems", "minItems", "uniqueItems" and
+// "unevaluatedItems".
+func (v *validator) validateArray(obj map[string]interface{}, arr []interface{}, sc *scope) {
+	prefix := 0
+	if subs, ok := obj["prefixItems"].([]interface{}); ok {
+		for idx, sch := range subs {
+			if idx >= len(arr) {
+				break
+			}
+			v.validateChild(sch, arr[idx], sc, "#"+strconv.Itoa(idx), "prefixItems/"+strconv.Itoa(idx))
+			sc.evaluated.items[idx] = true
+			prefix = idx + 1
+		}
+	}
+	if sch, ok := obj["items"]; ok {
+		for idx := prefix; idx < len(arr); idx++ {
+			v.validateChild(sch, arr[idx], sc, "#"+strconv.Itoa(idx), "items")
+		}
+		sc.evaluated.allItems = true
+	}
+
+	if sch, ok := obj["contains"]; ok {
+		matched := 0
+		for idx, item := range arr {
+			if v.validateIn(sch, item, sc.child("#"+strconv.Itoa(idx), "contains")) {
+				matched++
+				sc.evaluated.items[idx] = true
+			}
+		}
+		min := 1.
+		if m, ok := toFloat(obj["minContains"]); ok {
+			min = m
+		}
+		if float64(matched) < min {
+			sc.fail("contains", "Array contains %d matched items, expected at least %s", matched, formatNumber(min))
+		}
+		if max, ok := toFloat(obj["maxContains"]); ok && float64(matched) > max {
+			sc.fail("maxContains", "Array contains %d matched items, expected at most %s", matched, formatNumber(max))
+		}
+	}
+
+	if max, ok := toFloat(obj["maxItems"]); ok && float64(len(arr)) > max {
+		sc.fail("maxItems", "Array length %d is greater than the max items %s", len(arr), formatNumber(max))
+	}
+	if min, ok := toFloat(obj["minItems"]); ok && float64(len(arr)) < min {
+		sc.fail("minItems", "Array length %d is less than the min items %s", len(arr), formatNumber(min))
+	}
+	if unique, ok := obj["uniqueItems"].(bool); ok && unique {
+	outer:
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonq.Equal(arr[i], arr[j]) {
+					sc.fail("uniqueItems", "Array items #%d and #%d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	if sch, ok := obj["unevaluatedItems"]; ok && !sc.evaluated.allItems {
+		for idx, item := range arr {
+			if !sc.evaluated.items[idx] {
+				v.validateChild(sch, item, sc, "#"+strconv.Itoa(idx), "unevaluatedItems")
+			}
+		}
+		sc.evaluated.allItems = true
+	}
+}
+
+// Validate "properties", "patternProperties", "additionalProperties", "propertyNames", "maxProperties", "minProperties",
+// "required", "dependentRequired" and "unevaluatedProperties".
+func (v *validator) validateObject(obj map[string]interface{}, inst map[string]interface{}, sc *scope) {
+	keys := sortedKeys(inst)
+	props, _ := obj["properties"].(map[string]interface{})
+	patterns, _ := obj["patternProperties"].(map[string]interface{})
+	for _, key := range keys {
+		matched := false
+		if sch, ok := props[key]; ok {
+			v.validateChild(sch, inst[key], sc, jsonq.Escape(key), "properties/"+escapePointer(key))
+			matched = true
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			if v.schema.patterns[pattern].MatchString(key) {
+				v.validateChild(patterns[pattern], inst[key], sc, jsonq.Escape(key), "patternProperties/"+escapePointer(pattern))
+				matched = true
+			}
+		}
+		if sch, ok := obj["additionalProperties"]; ok && !matched {
+			v.validateChild(sch, inst[key], sc, jsonq.Escape(key), "additionalProperties")
+			matched = true
+		}
+		if matched {
+			sc.evaluated.props[key] = true
+		}
+		if sch, ok := obj["propertyNames"]; ok {
+			v.validateChild(sch, key, sc, jsonq.Escape(key), "propertyNames")
+		}
+	}
+
+	if max, ok := toFloat(obj["maxProperties"]); ok && float64(len(inst)) > max {
+		sc.fail("maxProperties", "Object has %d properties, expected at most %s", len(inst), formatNumber(max))
+	}
+	if min, ok := toFloat(obj["minProperties"]); ok && float64(len(inst)) < min {
+		sc.fail("minProperties", "Object has %d properties, expected at least %s", len(inst), formatNumber(min))
+	}
+	if required, ok := obj["required"].([]interface{}); ok {
+		for _, key := range required {
+			if key, ok := key.(string); ok {
+				if _, ok := inst[key]; !ok {
+					sc.fail("required", "Missing required property \"%s\"", key)
+				}
+			}
+		}
+	}
+	if deps, ok := obj["dependentRequired"].(map[string]interface{}); ok {
+		for _, key := range sortedKeys(deps) {
+			if _, ok := inst[key]; !ok {
+				continue
+			}
+			required, _ := deps[key].([]interface{})
+			for _, dep := range required {
+				if dep, ok := dep.(string); ok {
+					if _, ok := inst[dep]; !ok {
+						sc.fail("dependentRequired/"+escapePointer(key), "Missing property \"%s\" required by property \"%s\"", dep, key)
+					}
+				}
+			}
+		}
+	}
+
+	if sch, ok := obj["unevaluatedProperties"]; ok {
+		for _, key := range keys {
+			if !sc.evaluated.props[key] {
+				v.validateChild(sch, inst[key], sc, jsonq.Escape(key), "unevaluatedProperties")
+				sc.evaluated.props[key] = true
+			}
+		}
+	}
+}
+
+// Validate a child instance, the violations are collected into the parent scope.
+func (v *validator) validateChild(sch interface{}, inst interface{}, sc *scope, segment string, keyword string) {
+	sub := sc.child(segment, keyword)
+	v.validate(sch, inst, sub)
+	sc.errs = append(sc.errs, sub.errs...)
+}
+
+// Check if an instance matches a type name of JSON Schema.
+func matchType(typ string, inst interface{}) bool {
+	switch typ {
+	case "null":
+		return inst == nil
+	case "boolean":
+		_, ok := inst.(bool)
+		return ok
+	case "string":
+		_, ok := inst.(string)
+		return ok
+	case "array":
+		_, ok := inst.([]interface{})
+		return ok
+	case "object":
+		_, ok := inst.(map[string]interface{})
+		return ok
+	case "number":
+		_, ok := toFloat(inst)
+		return ok
+	case "integer":
+		num, ok := toFloat(inst)
+		return ok && !math.IsInf(num, 0) && num == math.Trunc(num)
+	}
+	return false
+}
+
+// Get the type name of an instance, integral numbers are "integer".
+func typeName(inst interface{}) string {
+	for _, typ := range []string{"null", "boolean", "string", "array", "object", "integer", "number"} {
+		if matchType(typ, inst) {
+			return typ
+		}
+	}
+	return fmt.Sprintf("%T", inst)
+}
+
+// Convert a number in the blob model to float64.
+func toFloat(val interface{}) (float64, bool) {
+	switch val := val.(type) {
+	case float64:
+		return val, true
+	case int64:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	}
+	return 0, false
+}
+
+// Format a number in messages without exponents.
+func formatNumber(num float64) string {
+	return strconv.FormatFloat(num, 'f', -1, 64)
+}
+
+// Get the sorted keys of an object, so that the violations are reported in a stable order.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"log"
+	"testing"
+)
+
+// Get the violations as "path | keyword".
+func violations(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		log.Fatalln(err)
+	}
+	out := make([]string, len(errs))
+	for idx, e := range errs {
+		out[idx] = e.Path + " | " + e.Keyword
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	s := mustCompile(`{
+		"type": "object",
+		"required": ["a", "c", "z"],
+		"properties": {
+			"a": {"type": "integer", "minimum": 0},
+			"c": {
+				"type": "object",
+				"properties": {
+					"e": {"enum": [0, 1]},
+					"f": {"type": "array", "items": {"$ref": "#/$defs/item"}, "maxItems": 2}
+				}
+			}
+		},
+		"$defs": {
+			"item": {
+				"required": ["g"],
+				"properties": {
+					"g": {"type": "integer", "exclusiveMaximum": 500},
+					"i": {"type": "string", "pattern": "^[a-f]+$", "maxLength": 3}
+				}
+			}
+		}
+	}`)
+
+	doc, err := jsonq.NewJsonDocument([]byte(`{
+		"a": "b",
+		"c": {
+			"e": 0,
+			"f": [
+				{"g": 123, "h": 0.3, "i": "abc"},
+				{"g": 456, "h": 0.6, "i": "def"},
+				{"g": 789, "h": 0.9, "i": "ghi"}
+			]
+		}
+	}`))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	err = s.Validate(doc)
+	xtesting.Equal(t, violations(err), []string{
+		"a | /properties/a/type",
+		"c f #2 g | /properties/c/properties/f/items/$ref/properties/g/exclusiveMaximum",
+		"c f #2 i | /properties/c/properties/f/items/$ref/properties/i/pattern",
+		"c f | /properties/c/properties/f/maxItems",
+		" | /required",
+	})
+	xtesting.Equal(t, err.Error(), "Expected type integer, got string at \"a\"\n"+
+		"Number 789 is not less than the exclusive maximum 500 at \"c f #2 g\"\n"+
+		"String \"ghi\" does not match the pattern \"^[a-f]+$\" at \"c f #2 i\"\n"+
+		"Array length 3 is greater than the max items 2 at \"c f\"\n"+
+		"Missing required property \"z\" at the root\n")
+
+	// subtrees
+	item := mustCompile(`{"$ref": "#/$defs/item", "$defs": {"item": {"properties": {"g": {"maximum": 500}}}}}`)
+	xtesting.Equal(t, item.ValidateBySelector(doc, "c f #0"), nil)
+	xtesting.Equal(t, violations(item.ValidateBySelector(doc, " c f #2 ")), []string{"c f #2 g | /$ref/properties/g/maximum"})
+	items := mustCompile(`{"type": "array", "items": {"properties": {"i": {"maxLength": 2}}}}`)
+	xtesting.Equal(t, violations(items.ValidateBySelector(doc, "c f")), []string{"c f #0 i | /items/properties/i/maxLength", "c f #1 i | /items/properties/i/maxLength", "c f #2 i | /items/properties/i/maxLength"})
+	_, err = jsonq.NewJsonQuery(doc).SelectBySelector("c x")
+	xtesting.Equal(t, errors.Is(item.ValidateBySelector(doc, "c x"), jsonq.ErrNotFound), errors.Is(err, jsonq.ErrNotFound))
+
+	// escaped keys
+	s = mustCompile(`{"additionalProperties": {"type": "string"}}`)
+	xtesting.Equal(t, violations(s.ValidateValue(map[string]interface{}{"a b": 1., "#c": 2., "d": "e"})), []string{"\\#c | /additionalProperties/type", "a\\ b | /additionalProperties/type"})
+}
+
+func TestValidateKeywords(t *testing.T) {
+	for _, tc := range []struct {
+		schema string
+		value  interface{}
+		want   []string
+	}{
+		{`{"type": ["string", "null"]}`, nil, nil},
+		{`{"type": "integer"}`, 1.5, []string{" | /type"}},
+		{`{"type": "number"}`, int64(1), nil},
+		{`{"const": {"a": [1]}}`, map[string]interface{}{"a": []interface{}{int64(1)}}, nil},
+		{`{"multipleOf": 0.1}`, 0.3, nil},
+		{`{"multipleOf": 2}`, 3., []string{" | /multipleOf"}},
+		{`{"minLength": 2, "maxLength": 2}`, "éé", nil},
+		{`{"minLength": 3}`, "éé", []string{" | /minLength"}},
+		{`{"uniqueItems": true}`, []interface{}{1., int64(1)}, []string{" | /uniqueItems"}},
+		{`{"prefixItems": [{"type": "string"}], "items": false}`, []interface{}{"a", 1.}, []string{"#1 | /items"}},
+		{`{"contains": {"type": "string"}}`, []interface{}{1.}, []string{" | /contains"}},
+		{`{"contains": {"type": "string"}, "minContains": 0}`, []interface{}{1.}, nil},
+		{`{"contains": {"type": "string"}, "maxContains": 1}`, []interface{}{"a", "b"}, []string{" | /maxContains"}},
+		{`{"minProperties": 1, "maxProperties": 1}`, map[string]interface{}{}, []string{" | /minProperties"}},
+		{`{"patternProperties": {"^x": {"type": "integer"}}, "additionalProperties": false}`, map[string]interface{}{"x1": 1., "y": 1.}, []string{"y | /additionalProperties"}},
+		{`{"propertyNames": {"maxLength": 1}}`, map[string]interface{}{"ab": 1.}, []string{"ab | /propertyNames/maxLength"}},
+		{`{"dependentRequired": {"a": ["b"]}}`, map[string]interface{}{"a": 1.}, []string{" | /dependentRequired/a"}},
+		{`{"dependentSchemas": {"a": {"required": ["b"]}}}`, map[string]interface{}{"a": 1.}, []string{" | /dependentSchemas/a/required"}},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 0}]}`, 2., []string{" | /allOf/1/maximum"}},
+		{`{"anyOf": [{"type": "string"}, {"minimum": 3}]}`, 2., []string{" | /anyOf"}},
+		{`{"oneOf": [{"type": "number"}, {"minimum": 3}]}`, 4., []string{" | /oneOf"}},
+		{`{"oneOf": [{"type": "number"}, {"minimum": 3}]}`, 2., nil},
+		{`{"not": {"type": "string"}}`, "a", []string{" | /not"}},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"minimum": 2}}`, "a", []string{" | /then/minLength"}},
+		{`{"if": {"type": "string"}, "then": {"minLength": 2}, "else": {"minimum": 2}}`, 1., []string{" | /else/minimum"}},
+		{`{"properties": {"a": true}, "allOf": [{"properties": {"b": true}}], "unevaluatedProperties": false}`, map[string]interface{}{"a": 1., "b": 1., "c": 1.}, []string{"c | /unevaluatedProperties"}},
+		{`{"anyOf": [{"properties": {"a": true}, "required": ["a"]}, {"properties": {"b": true}, "required": ["x"]}], "unevaluatedProperties": false}`, map[string]interface{}{"a": 1., "b": 1.}, []string{"b | /unevaluatedProperties"}},
+		{`{"prefixItems": [true], "contains": {"type": "string"}, "unevaluatedItems": false}`, []interface{}{1., "a", 2.}, []string{"#2 | /unevaluatedItems"}},
+		{`{"$ref": "#/$defs/a", "$defs": {"a": {"items": true}}, "unevaluatedItems": false}`, []interface{}{1.}, nil},
+		{`{"$defs": {"node": {"properties": {"next": {"$ref": "#/$defs/node"}, "v": {"type": "integer"}}}}, "$ref": "#/$defs/node"}`,
+			map[string]interface{}{"v": 1., "next": map[string]interface{}{"next": map[string]interface{}{"v": "x"}}}, []string{"next next v | /$ref/properties/next/$ref/properties/next/$ref/properties/v/type"}},
+		{`{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`, 1., []string{" | /$ref/$ref"}},
+		{`{"properties": {"a": false}}`, map[string]interface{}{"a": 1.}, []string{"a | /properties/a"}},
+	} {
+		xtesting.Equal(t, violations(mustCompile(tc.schema).ValidateValue(tc.value)), tc.want)
+	}
+}
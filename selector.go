@@ -0,0 +1,59 @@
+package jsonq
+
+// A compiled selector, which could be used repeatedly without parsing the selector string again.
+type Selector struct {
+	str    string
+	tokens []interface{}
+}
+
+// Compile a selector string to a Selector.
+func Compile(selectorString string) (*Selector, error) {
+	tokens, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{str: selectorString, tokens: tokens}, nil
+}
+
+// Compile a selector string to a Selector, it panics if the selector string has a syntax error.
+func MustCompile(selectorString string) *Selector {
+	s, err := Compile(selectorString)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Get the original selector string.
+func (s *Selector) String() string {
+	return s.str
+}
+
+// Get the tokens of the selector, which could be used in Select.
+func (s *Selector) Tokens() []interface{} {
+	out := make([]interface{}, len(s.tokens))
+	copy(out, s.tokens)
+	return out
+}
+
+// Check if the selector selects multiple values, which are returned as an array, that is, it has a multi token, an
+// all-fields token, a key pattern or an array slice, which is not followed by a function.
+func (s *Selector) Multi() bool {
+	multi := false
+	for _, token := range s.tokens {
+		switch token.(type) {
+		case *funcToken:
+			multi = false
+		case *multiToken:
+			multi = true
+		default:
+			multi = multi || isExpandable(token)
+		}
+	}
+	return multi
+}
+
+// Query json by a compiled selector.
+func (j *JsonQuery) SelectCompiled(selector *Selector) (interface{}, error) {
+	return j.Select(selector.tokens...)
+}
@@ -0,0 +1,40 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+	"unsafe"
+)
+
+func TestCompile(t *testing.T) {
+	bytes := *(*[]byte)(unsafe.Pointer(&objDoc))
+	doc, err := NewJsonDocument(bytes)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	sel := MustCompile("c f * g")
+	xtesting.Equal(t, sel.String(), "c f * g")
+	xtesting.Equal(t, sel.Tokens(), []interface{}{"c", "f", All(), "g"})
+
+	val1 := handle(jq.SelectCompiled(sel))
+	val2 := handle(jq.Select(sel.Tokens()...))
+	xtesting.Equal(t, val1, []interface{}{123., 456., 789.})
+	xtesting.Equal(t, val2, []interface{}{123., 456., 789.})
+
+	for str, multi := range map[string]bool{
+		"": false, "a b": false, "a*b": false, "a+b": true, "*": true, "#0:": true, "~a*": true, "~/a/": true, "* | count": false, "a | count #0+#1": true,
+	} {
+		xtesting.Equal(t, MustCompile(str).Multi(), multi)
+	}
+
+	_, err = Compile("a |")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	defer func() {
+		xtesting.NotEqual(t, recover(), nil)
+	}()
+	MustCompile("#a")
+}
@@ -0,0 +1,86 @@
+package jsonq
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A location of a value in the original input. The offsets start from 0, and the lines and columns start from 1, the
+// columns count characters.
+type Span struct {
+	Start       int // offset of the first byte
+	End         int // offset after the last byte
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+}
+
+// A byte range [start, end) in the input.
+type spanRange struct {
+	start int
+	end   int
+}
+
+// Spans of a document parsed with RetainSpans.
+type spanIndex struct {
+	data []byte
+
+	// a blob in the same shape as the document, whose scalars are replaced by spanRange, so that it could be queried by
+	// the same tokens as the document
+	blob interface{}
+
+	// ranges of the objects and arrays in blob, the keys are their pointers
+	containers map[uintptr]spanRange
+}
+
+// Get the Span of a value in the span blob.
+func (s *spanIndex) span(val interface{}) (*Span, error) {
+	r, ok := val.(spanRange)
+	if !ok {
+		r, ok = s.containers[reflect.ValueOf(val).Pointer()]
+	}
+	if !ok { // collected by CollectDuplicates
+		return nil, fmt.Errorf("The value has no span in the input\n")
+	}
+	startLine, startColumn := position(s.data, r.start)
+	endLine, endColumn := position(s.data, r.end)
+	return &Span{Start: r.start, End: r.end, StartLine: startLine, StartColumn: startColumn, EndLine: endLine, EndColumn: endColumn}, nil
+}
+
+// Query the spans of the values selected by the tokens, in the same order as Select. The document must be parsed with
+// RetainSpans, and the tokens could not contain functions.
+func (j *JsonQuery) Spans(tokens ...interface{}) ([]*Span, error) {
+	if j.doc.spans == nil {
+		return nil, fmt.Errorf("The document does not retain spans, parse it with RetainSpans\n")
+	}
+	for _, token := range tokens {
+		if ftok, ok := token.(*funcToken); ok {
+			return nil, kindErrorf(ErrSyntax, "Function \"%s\" has no span\n", ftok.name)
+		}
+	}
+	if _, err := j.Select(tokens...); err != nil { // report the same error as Select
+		return nil, err
+	}
+
+	vals, _, err := j.rquery(j.doc.spans.blob, tokens...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Span, len(vals))
+	for idx, val := range vals {
+		if out[idx], err = j.doc.spans.span(val); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Query the spans of the values selected by a selector string, see Spans.
+func (j *JsonQuery) SpansBySelector(selectorString string) ([]*Span, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Spans(selector...)
+}
@@ -0,0 +1,58 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestSpans(t *testing.T) {
+	input := []byte("{\n\t\"a\": \"b\",\n\t\"c\": [1, {\"d\": true}, []],\n\t\"é\": null\n}")
+	doc, err := NewJsonDocumentWithOptions(input, ParseOptions{RetainSpans: true})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	texts := func(spans []*Span) []string {
+		out := make([]string, len(spans))
+		for idx, span := range spans {
+			out[idx] = string(input[span.Start:span.End])
+		}
+		return out
+	}
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("")).([]*Span)), []string{string(input)})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("a")).([]*Span)), []string{`"b"`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("c")).([]*Span)), []string{`[1, {"d": true}, []]`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("c *")).([]*Span)), []string{`1`, `{"d": true}`, `[]`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("c #1 d")).([]*Span)), []string{`true`})
+	xtesting.Equal(t, texts(handle(jq.Spans("c", -1)).([]*Span)), []string{`[]`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("a+é")).([]*Span)), []string{`"b"`, `null`})
+
+	spans := handle(jq.SpansBySelector("é")).([]*Span)
+	xtesting.Equal(t, *spans[0], Span{Start: 48, End: 52, StartLine: 4, StartColumn: 7, EndLine: 4, EndColumn: 11})
+	spans = handle(jq.SpansBySelector("c")).([]*Span)
+	xtesting.Equal(t, *spans[0], Span{Start: 19, End: 39, StartLine: 3, StartColumn: 7, EndLine: 3, EndColumn: 27})
+
+	// also for other syntaxes and case-insensitive keys
+	input = []byte("// comment\n{A: [1, 'x',], b: {c: 0x10}}")
+	doc, _ = NewJsonDocumentWithOptions(input, ParseOptions{RetainSpans: true, Syntax: SyntaxJson5})
+	jq = NewJsonQuery(doc, WithCaseInsensitiveKeys())
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("a #1")).([]*Span)), []string{`'x'`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("B C")).([]*Span)), []string{`0x10`})
+	xtesting.Equal(t, texts(handle(jq.SpansBySelector("~/^[Ab]$/")).([]*Span)), []string{"[1, 'x',]", "{c: 0x10}"})
+
+	_, err = jq.SpansBySelector("x")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = jq.SpansBySelector("a | count")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	doc, _ = NewJsonDocument([]byte(`{"a": 1}`))
+	_, err = NewJsonQuery(doc).SpansBySelector("a")
+	xtesting.NotEqual(t, err, nil)
+	input = []byte(`{"a": 1, "a": 2}`)
+	doc, _ = NewJsonDocumentWithOptions(input, ParseOptions{RetainSpans: true, DuplicateKeys: CollectDuplicates})
+	_, err = NewJsonQuery(doc).SpansBySelector("a")
+	xtesting.NotEqual(t, err, nil)
+	xtesting.Equal(t, texts(handle(NewJsonQuery(doc).SpansBySelector("a #1")).([]*Span)), []string{`2`})
+}
@@ -0,0 +1,217 @@
+package jsonq
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Query a json stream by a compiled selector without unmarshaling the whole input, only the matched values are unmarshaled
+// and passed to fn one by one, so that selectors like "#0:100 name" and "* id" run in bounded memory over a huge array.
+//
+// The results are the same as Select except that: the fields of an object selected by a multiToken, a starToken or a key
+// pattern are passed in the order of the input, instead of the order of the tokens or the sorted order of the keys, since
+// they are not buffered; negative array indexes and slice bounds, and functions are not supported; and when the selector
+// only selects the leading items of the top-level array, the rest of the input is not read.
+func SelectStream(r io.Reader, selector *Selector, fn func(val interface{}) error) error {
+	for _, token := range selector.tokens {
+		if err := checkStreamToken(token); err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	return streamValue(dec, selector.tokens, fn, true)
+}
+
+// Check if the token is supported in streaming.
+func checkStreamToken(token interface{}) error {
+	switch tok := token.(type) {
+	case int:
+		if tok < 0 {
+			return kindErrorf(ErrSyntax, "Negative array index %d is not supported in streaming\n", tok)
+		}
+	case *sliceToken:
+		if tok.start < 0 || (!tok.open && tok.end < 0) {
+			return kindErrorf(ErrSyntax, "Negative array slice %v is not supported in streaming\n", tok)
+		}
+	case *funcToken:
+		return kindErrorf(ErrSyntax, "Function \"%s\" is not supported in streaming\n", tok.name)
+	case *multiToken:
+		for _, stok := range tok.sels {
+			if err := checkStreamToken(stok); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stream the value at the current position of the decoder, by the rest tokens.
+func streamValue(dec *json.Decoder, tokens []interface{}, fn func(val interface{}) error, root bool) error {
+	if len(tokens) == 0 {
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		return fn(val)
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	sels := []interface{}{token}
+	if mtok, ok := token.(*multiToken); ok {
+		sels = mtok.sels
+	}
+	found := make([]bool, len(sels)) // for single tokens
+
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t {
+	case json.Delim('{'):
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := kt.(string)
+			if matchStreamSels(sels, found, func(stok interface{}) bool {
+				switch stok := stok.(type) {
+				case string:
+					return stok == key
+				case *starToken:
+					return true
+				case *keyGlobToken:
+					return matchGlob(stok.pattern, key)
+				case *keyRegexToken:
+					return stok.re.MatchString(key)
+				}
+				return false
+			}) {
+				err = streamValue(dec, rest, fn, false)
+			} else {
+				err = skipStreamValue(dec)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil { // }
+			return err
+		}
+		for idx, stok := range sels {
+			switch stok.(type) {
+			case string:
+				if !found[idx] {
+					return kindErrorf(ErrNotFound, "Object does not contain field \"%s\"\n", stok)
+				}
+			case int, *sliceToken:
+				return kindErrorf(ErrTypeMismatch, "Array index %v on non-array\n", stok)
+			}
+		}
+
+	case json.Delim('['):
+		limit := streamLimit(sels)
+		for idx := 0; dec.More(); idx++ {
+			if root && limit >= 0 && idx >= limit { // the rest items will never be selected
+				return checkStreamArraySels(sels, found)
+			}
+			if matchStreamSels(sels, found, func(stok interface{}) bool {
+				switch stok := stok.(type) {
+				case int:
+					return stok == idx
+				case *starToken:
+					return true
+				case *sliceToken:
+					return idx >= stok.start && (stok.open || idx < stok.end)
+				}
+				return false
+			}) {
+				err = streamValue(dec, rest, fn, false)
+			} else {
+				err = skipStreamValue(dec)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil { // ]
+			return err
+		}
+		return checkStreamArraySels(sels, found)
+
+	default:
+		return kindErrorf(ErrTypeMismatch, "Input %v is a non-array and non-object\n", t)
+	}
+	return nil
+}
+
+// Check if the sels are all found in an array.
+func checkStreamArraySels(sels []interface{}, found []bool) error {
+	for idx, stok := range sels {
+		switch stok.(type) {
+		case int:
+			if !found[idx] {
+				return kindErrorf(ErrNotFound, "Array index %d out of bound\n", stok)
+			}
+		case string, *keyGlobToken, *keyRegexToken:
+			return kindErrorf(ErrTypeMismatch, "Object lookup %v on non-array\n", stok)
+		}
+	}
+	return nil
+}
+
+// Check if any of the sels matches, and mark the matched ones as found.
+func matchStreamSels(sels []interface{}, found []bool, match func(stok interface{}) bool) bool {
+	matched := false
+	for idx, stok := range sels {
+		if match(stok) {
+			found[idx] = true
+			matched = true
+		}
+	}
+	return matched
+}
+
+// Get the max index (exclusive) which could be selected by the sels in an array, -1 means unlimited.
+func streamLimit(sels []interface{}) int {
+	limit := 0
+	for _, stok := range sels {
+		switch stok := stok.(type) {
+		case int:
+			if stok+1 > limit {
+				limit = stok + 1
+			}
+		case *sliceToken:
+			if stok.open {
+				return -1
+			}
+			if stok.end > limit {
+				limit = stok.end
+			}
+		case *starToken:
+			return -1
+		}
+	}
+	return limit
+}
+
+// Skip the value at the current position of the decoder.
+func skipStreamValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
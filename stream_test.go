@@ -0,0 +1,122 @@
+package jsonq
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type failReader struct{}
+
+func (failReader) Read([]byte) (int, error) {
+	return 0, errors.New("should not read here")
+}
+
+func streamAll(input string, selector string) ([]interface{}, error) {
+	out := make([]interface{}, 0)
+	err := SelectStream(strings.NewReader(input), MustCompile(selector), func(val interface{}) error {
+		out = append(out, val)
+		return nil
+	})
+	return out, err
+}
+
+func TestSelectStream(t *testing.T) {
+	bytes := *(*[]byte)(unsafe.Pointer(&arrDoc))
+	doc, _ := NewJsonDocument(bytes)
+	jq := NewJsonQuery(doc)
+
+	for _, selector := range []string{"#0 *", "#1 b *", "#1+#2+#3 a", "#3 b f #0:2 g", "#3 b f #3:", "#1:4 a", "#1 b+bb c", "#2 b f * ~/^g$/+h", "#1:4 b c", "#3 b f #4 #1"} {
+		expected, err := jq.SelectBySelector(selector)
+		xtesting.Equal(t, err, nil)
+		if arr, ok := expected.([]interface{}); !ok || !isMultiSelectorForTest(selector) {
+			expected = []interface{}{expected}
+		} else {
+			expected = arr
+		}
+		vals, err := streamAll(arrDoc, selector)
+		xtesting.Equal(t, err, nil)
+		xtesting.Equal(t, sortedHashes(vals), sortedHashes(expected.([]interface{})))
+	}
+
+	_, err := streamAll(arrDoc, "#1 x")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = streamAll(arrDoc, "#9")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = streamAll(arrDoc, "a")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = streamAll(arrDoc, "#1 a x")
+	xtesting.Equal(t, errors.Is(err, ErrTypeMismatch), true)
+	_, err = streamAll(arrDoc, "#-1")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = streamAll(arrDoc, "#0:-1")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = streamAll(arrDoc, "* | count")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+	_, err = streamAll(`[{"a": 1}, {"a": `, "* a")
+	xtesting.NotEqual(t, err, nil)
+
+	// the fields of an object are in the order of the input, unlike Select
+	vals, err := streamAll(`{"b": 2, "c": 3, "a": 1, "ab": 4}`, "*")
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, vals, []interface{}{2., 3., 1., 4.})
+	vals, err = streamAll(`{"b": 2, "c": 3, "a": 1, "ab": 4}`, "~/^a|b$/+c")
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, vals, []interface{}{2., 3., 1., 4.})
+
+	stop := errors.New("stop")
+	err = SelectStream(strings.NewReader(arrDoc), MustCompile("*"), func(val interface{}) error {
+		return stop
+	})
+	xtesting.Equal(t, err, stop)
+}
+
+func TestSelectStreamLarge(t *testing.T) {
+	sb := &strings.Builder{}
+	sb.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		_, _ = fmt.Fprintf(sb, `{"id": %d, "name": "n%d", "tags": ["a", "b"], "skip": {"x": [1, {"y": 2}]}}`, i, i)
+	}
+	prefix := sb.String()
+
+	// the input is never ended, but only the first 100 items are read
+	r := io.MultiReader(strings.NewReader(prefix), failReader{})
+	count := 0
+	err := SelectStream(r, MustCompile("#0:100 name"), func(val interface{}) error {
+		xtesting.Equal(t, val, fmt.Sprintf("n%d", count))
+		count++
+		return nil
+	})
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, count, 100)
+
+	ids := make([]interface{}, 0)
+	err = SelectStream(strings.NewReader(prefix+"]"), MustCompile("* id"), func(val interface{}) error {
+		ids = append(ids, val)
+		return nil
+	})
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, len(ids), 1000)
+	xtesting.Equal(t, ids[999], 999.)
+}
+
+func sortedHashes(vals []interface{}) []string {
+	out := make([]string, len(vals))
+	for idx, val := range vals {
+		out[idx], _ = Hash(val)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isMultiSelectorForTest(selector string) bool {
+	return strings.ContainsAny(selector, "*+:/")
+}
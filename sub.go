@@ -0,0 +1,27 @@
+package jsonq
+
+// Select a value by the tokens, and return a new JsonQuery rooted at it, so that the following queries (such as the
+// typed getters) are relative to the value. The value is shared with the document without copying, and the results of
+// multiToken and starToken are treated as an array. The new JsonQuery keeps the query options, but not the spans, so
+// Spans and SpansBySelector could not be used with it.
+func (j *JsonQuery) Sub(tokens ...interface{}) (*JsonQuery, error) {
+	val, err := j.Select(tokens...)
+	if err != nil {
+		return nil, err
+	}
+	return j.withBlob(val), nil
+}
+
+// Select a value by a selector string, and return a new JsonQuery rooted at it, see Sub.
+func (j *JsonQuery) SubBySelector(selectorString string) (*JsonQuery, error) {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return j.Sub(selector...)
+}
+
+// Create a new JsonQuery rooted at the given blob.
+func (j *JsonQuery) withBlob(blob interface{}) *JsonQuery {
+	return &JsonQuery{doc: &JsonDocument{blob: blob}, normalize: j.normalize}
+}
@@ -0,0 +1,52 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"testing"
+)
+
+func TestSub(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(objDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	jq := NewJsonQuery(doc)
+
+	sub, err := jq.Sub("c", "j")
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, handle(sub.Int64("l", 1, -1)), int64(6))
+	xtesting.Equal(t, handle(sub.SelectBySelector("k")), nil)
+	xtesting.Equal(t, handle(sub.Keys()), []string{"k", "l"})
+
+	// nested and without copying
+	sub2 := handle(sub.SubBySelector("l #0")).(*JsonQuery)
+	xtesting.Equal(t, handle(sub2.Int64s(All())), []int64{1, 2, 3})
+	arr1, arr2 := handle(jq.SelectBySelector("c j l #0")).([]interface{}), handle(sub2.Select()).([]interface{})
+	xtesting.Equal(t, &arr1[0], &arr2[0])
+
+	// multiple results, query options and errors
+	sub3 := handle(jq.SubBySelector("c f * i")).(*JsonQuery)
+	xtesting.Equal(t, handle(sub3.Strings(Slice(1, 3))), []string{"def", "ghi"})
+	doc, err = NewJsonDocumentWithOptions([]byte(`{"A": {"B": 1}}`), ParseOptions{Lazy: true})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sub4 := handle(NewJsonQuery(doc, WithCaseInsensitiveKeys()).Sub("a")).(*JsonQuery)
+	xtesting.Equal(t, handle(sub4.Int64("b")), int64(1))
+	_, err = jq.SubBySelector("c x")
+	xtesting.Equal(t, errors.Is(err, ErrNotFound), true)
+	_, err = jq.SubBySelector("c |")
+	xtesting.Equal(t, errors.Is(err, ErrSyntax), true)
+
+	// the spans are not kept
+	doc, err = NewJsonDocumentWithOptions([]byte(`{"a": {"b": 1}}`), ParseOptions{RetainSpans: true})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	xtesting.Equal(t, len(handle(NewJsonQuery(doc).SpansBySelector("a b")).([]*Span)), 1)
+	sub5 := handle(NewJsonQuery(doc).Sub("a")).(*JsonQuery)
+	_, err = sub5.Spans("b")
+	xtesting.NotEqual(t, err, nil)
+}
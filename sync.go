@@ -0,0 +1,185 @@
+package jsonq
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A document which could be queried by many goroutines while being updated. Each update copies the objects and arrays
+// along the updated path (copy-on-write) and publishes a new snapshot, so that a snapshot is never changed once it is
+// got, and the readers are not blocked by the copying.
+type SyncDocument struct {
+	wmu sync.Mutex   // serializes the writers
+	mu  sync.RWMutex // guards doc
+	doc *JsonDocument
+}
+
+// Create a SyncDocument from a document, the document is used as the first snapshot, and it must not be used after
+// being wrapped, except by the snapshots. A lazy document is fully decoded by the first update, because the values not
+// on the updated path are shared with the new snapshot.
+func NewSyncDocument(doc *JsonDocument) *SyncDocument {
+	return &SyncDocument{doc: doc}
+}
+
+// Get the current snapshot, it is immutable and could be queried by many goroutines concurrently. The snapshots
+// after the first update do not retain spans.
+func (s *SyncDocument) Snapshot() *JsonDocument {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc
+}
+
+// Create a JsonQuery of the current snapshot, see Snapshot.
+func (s *SyncDocument) Query(options ...QueryOption) *JsonQuery {
+	return NewJsonQuery(s.Snapshot(), options...)
+}
+
+// Set the value at the tokens, which could only be object keys (strings) and array indexes (integers). The missing key
+// is added to the last object, but the other tokens must exist. Empty tokens replace the root, which must be a map or a
+// slice. The value is converted by the rules of NewJsonDocumentFromValue, so it is copied and could be reused.
+func (s *SyncDocument) Set(value interface{}, tokens ...interface{}) error {
+	val, err := convertValue(reflect.ValueOf(value))
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		switch val.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return fmt.Errorf("Expected a map or a slice as the root value, got %T\n", value)
+		}
+		s.wmu.Lock()
+		defer s.wmu.Unlock()
+		s.publish(val)
+		return nil
+	}
+	return s.update(tokens, func(parent interface{}, token interface{}) (interface{}, error) {
+		if _, ok := token.(string); ok {
+			if _, ok := parent.(map[string]interface{}); ok {
+				return replaceChild(parent, token, val), nil // set or add
+			}
+		}
+		if _, err := (&JsonQuery{}).query(parent, token); err != nil {
+			return nil, err
+		}
+		return replaceChild(parent, token, val), nil
+	})
+}
+
+// Set the value at a selector string, see Set.
+func (s *SyncDocument) SetBySelector(selectorString string, value interface{}) error {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return err
+	}
+	return s.Set(value, selector...)
+}
+
+// Delete the value at the tokens, which could only be object keys (strings) and array indexes (integers). The items
+// after the deleted one in an array are moved forward. The root could not be deleted.
+func (s *SyncDocument) Delete(tokens ...interface{}) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("Could not delete the root value\n")
+	}
+	return s.update(tokens, func(parent interface{}, token interface{}) (interface{}, error) {
+		if _, err := (&JsonQuery{}).query(parent, token); err != nil {
+			return nil, err
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(p))
+			for key, val := range p {
+				if key != token.(string) {
+					out[key] = val
+				}
+			}
+			return out, nil
+		case []interface{}:
+			idx := arrayIndex(p, token.(int))
+			out := make([]interface{}, 0, len(p)-1)
+			return append(append(out, p[:idx]...), p[idx+1:]...), nil
+		}
+		return parent, nil
+	})
+}
+
+// Delete the value at a selector string, see Delete.
+func (s *SyncDocument) DeleteBySelector(selectorString string) error {
+	selector, err := _NewParser(selectorString).Parse()
+	if err != nil {
+		return err
+	}
+	return s.Delete(selector...)
+}
+
+// Apply a change to the parent of the last token by copying the path, and publish the new snapshot.
+func (s *SyncDocument) update(tokens []interface{}, change func(parent interface{}, token interface{}) (interface{}, error)) error {
+	for _, token := range tokens {
+		switch token.(type) {
+		case string, int:
+		default:
+			return kindErrorf(ErrSyntax, "Only object keys and array indexes could be updated, got %v\n", token)
+		}
+	}
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	root, err := copyPath(s.Snapshot().value(), tokens, change)
+	if err != nil {
+		return err
+	}
+	s.publish(root)
+	return nil
+}
+
+// Publish a new snapshot of the root, the caller must hold wmu.
+func (s *SyncDocument) publish(root interface{}) {
+	s.mu.Lock()
+	s.doc = &JsonDocument{blob: root}
+	s.mu.Unlock()
+}
+
+// Copy the objects and arrays along the tokens, and apply the change to the parent of the last token, returns the new
+// root. The values which are not on the path are shared with the old root.
+func copyPath(blob interface{}, tokens []interface{}, change func(parent interface{}, token interface{}) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return change(blob, tokens[0])
+	}
+	child, err := (&JsonQuery{}).query(blob, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+	newChild, err := copyPath(child, tokens[1:], change)
+	if err != nil {
+		return nil, err
+	}
+	return replaceChild(blob, tokens[0], newChild), nil
+}
+
+// Copy an object or an array with a child replaced, the token must be valid for the container.
+func replaceChild(blob interface{}, token interface{}, val interface{}) interface{} {
+	switch b := blob.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(b)+1)
+		for key, v := range b {
+			out[key] = v
+		}
+		out[token.(string)] = val
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(b))
+		copy(out, b)
+		out[arrayIndex(b, token.(int))] = val
+		return out
+	}
+	return blob
+}
+
+// Convert a negative index to the index from the beginning.
+func arrayIndex(arr []interface{}, idx int) int {
+	if idx < 0 {
+		return idx + len(arr)
+	}
+	return idx
+}
@@ -0,0 +1,81 @@
+package jsonq
+
+import (
+	"errors"
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"log"
+	"sync"
+	"testing"
+)
+
+func TestSyncDocument(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(objDoc))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sd := NewSyncDocument(doc)
+	old := sd.Snapshot()
+	xtesting.Equal(t, old, doc)
+
+	xtesting.Equal(t, sd.Set(1, "c", "e"), nil)
+	xtesting.Equal(t, sd.SetBySelector("c f #-1 g", map[string]int{"x": 2}), nil)
+	xtesting.Equal(t, sd.Set([]string{"y"}, "c", "n"), nil)
+	xtesting.Equal(t, sd.DeleteBySelector("c f #0"), nil)
+	xtesting.Equal(t, sd.Delete("a"), nil)
+
+	jq := sd.Query()
+	xtesting.Equal(t, handle(jq.Int64("c", "e")), int64(1))
+	xtesting.Equal(t, handle(jq.Int64BySelector("c f #1 g x")), int64(2))
+	xtesting.Equal(t, handle(jq.StringsBySelector("c n *")), []string{"y"})
+	xtesting.Equal(t, handle(jq.StringsBySelector("c f * i")), []string{"def", "ghi"})
+	xtesting.Equal(t, handle(jq.Keys()), []string{"c"})
+
+	// the old snapshot is not changed, and the untouched values are shared
+	oldJq := NewJsonQuery(old)
+	xtesting.Equal(t, handle(oldJq.Int64("c", "e")), int64(0))
+	xtesting.Equal(t, handle(oldJq.Int64BySelector("c f #2 g")), int64(789))
+	xtesting.Equal(t, handle(oldJq.LenBySelector("c f")), 3)
+	xtesting.Equal(t, handle(oldJq.String("a")), "b")
+	l1, l2 := handle(oldJq.SelectBySelector("c j l")).([]interface{}), handle(jq.SelectBySelector("c j l")).([]interface{})
+	xtesting.Equal(t, &l1[0], &l2[0])
+
+	// replace the root and errors
+	xtesting.Equal(t, sd.Set([]int{1, 2}), nil)
+	xtesting.Equal(t, handle(sd.Query().Int64s(All())), []int64{1, 2})
+	xtesting.NotEqual(t, sd.Set(1), nil)
+	xtesting.NotEqual(t, sd.Delete(), nil)
+	xtesting.Equal(t, errors.Is(sd.Set(0, 2), ErrNotFound), true)
+	xtesting.Equal(t, errors.Is(sd.Delete(0, "a"), ErrTypeMismatch), true)
+	xtesting.Equal(t, errors.Is(sd.Set(0, "a"), ErrTypeMismatch), true)
+	xtesting.Equal(t, errors.Is(sd.SetBySelector("*", 0), ErrSyntax), true)
+	xtesting.Equal(t, errors.Is(sd.DeleteBySelector("#0 |"), ErrSyntax), true)
+	xtesting.NotEqual(t, sd.Set(make(chan int), 0), nil)
+	xtesting.Equal(t, handle(sd.Query().Int64s(All())), []int64{1, 2})
+}
+
+func TestSyncDocumentConcurrency(t *testing.T) {
+	doc, _ := NewJsonDocumentWithOptions([]byte(`{"n": 0, "items": []}`), ParseOptions{Lazy: true})
+	sd := NewSyncDocument(doc)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				jq := sd.Query()
+				n, err := jq.Int64("n")
+				xtesting.Equal(t, err, nil)
+				xtesting.Equal(t, handle(jq.Len("items")).(int) >= int(n), true)
+			}
+		}()
+	}
+	for k := 1; k <= 100; k++ {
+		jq := sd.Query()
+		items := handle(jq.Select("items")).([]interface{})
+		xtesting.Equal(t, sd.Set(append(items, k), "items"), nil)
+		xtesting.Equal(t, sd.Set(k, "n"), nil)
+	}
+	wg.Wait()
+	xtesting.Equal(t, handle(sd.Query().Len("items")), 100)
+}
@@ -0,0 +1,11 @@
+module github.com/Aoi-hosizora/jsonq/toml
+
+go 1.18
+
+require (
+	github.com/Aoi-hosizora/ahlib v1.3.0
+	github.com/Aoi-hosizora/jsonq v0.0.0
+	github.com/BurntSushi/toml v1.4.0
+)
+
+replace github.com/Aoi-hosizora/jsonq => ../
@@ -0,0 +1,17 @@
+// Package toml creates json documents of jsonq from toml, so that toml could be queried by the same selectors.
+package toml
+
+import (
+	"github.com/Aoi-hosizora/jsonq"
+	burntsushi "github.com/BurntSushi/toml"
+)
+
+// Create a JsonDocument from toml, the values are converted by jsonq.NewJsonDocumentFromValue, and the local date, time
+// and date-time become "2006-01-02", "15:04:05" and "2006-01-02T15:04:05" strings.
+func NewJsonDocument(data []byte) (*jsonq.JsonDocument, error) {
+	v := make(map[string]interface{})
+	if err := burntsushi.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return jsonq.NewJsonDocumentFromValue(v)
+}
@@ -0,0 +1,56 @@
+package toml
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"log"
+	"testing"
+)
+
+func handle(obj interface{}, err error) interface{} {
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return obj
+}
+
+func TestNewJsonDocument(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(`
+title = "example"
+port = 8080
+ratio = 0.5
+enabled = true
+odt = 1979-05-27T07:32:00-08:00
+ldt = 1979-05-27T07:32:00.5
+ld = 1979-05-27
+lt = 07:32:00
+
+[owner]
+name = "Tom"
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+name = "Nail"
+colors = ["gray", "black"]
+`))
+	xtesting.Equal(t, err, nil)
+	jq := jsonq.NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.StringBySelector("title")), "example")
+	xtesting.Equal(t, handle(jq.SelectBySelector("port")), 8080.)
+	xtesting.Equal(t, handle(jq.Int64BySelector("port")), int64(8080))
+	xtesting.Equal(t, handle(jq.Float64BySelector("ratio")), 0.5)
+	xtesting.Equal(t, handle(jq.BoolBySelector("enabled")), true)
+	xtesting.Equal(t, handle(jq.StringBySelector("odt")), "1979-05-27T15:32:00Z")
+	xtesting.Equal(t, handle(jq.StringBySelector("ldt")), "1979-05-27T07:32:00.5")
+	xtesting.Equal(t, handle(jq.StringBySelector("ld")), "1979-05-27")
+	xtesting.Equal(t, handle(jq.StringBySelector("lt")), "07:32:00")
+	xtesting.Equal(t, handle(jq.StringBySelector("owner name")), "Tom")
+	xtesting.Equal(t, handle(jq.SelectBySelector("products * name")), []interface{}{"Hammer", "Nail"})
+	xtesting.Equal(t, handle(jq.SelectBySelector("products #1 colors #-1")), "black")
+
+	_, err = NewJsonDocument([]byte("a = "))
+	xtesting.NotEqual(t, err, nil)
+}
@@ -1,14 +1,10 @@
 package jsonq
 
-import (
-	"fmt"
-)
-
 func interfaceToBool(i interface{}) (bool, error) {
 	if b, ok := i.(bool); ok {
 		return b, nil
 	}
-	return false, fmt.Errorf("Excepted a bool value, got \"%v\"\n", i)
+	return false, kindErrorf(ErrTypeMismatch, "Excepted a bool value, got \"%v\"\n", i)
 }
 
 func interfaceToInt64(i interface{}) (int64, error) {
@@ -18,7 +14,7 @@ func interfaceToInt64(i interface{}) (int64, error) {
 	case float64:
 		return int64(i.(float64)), nil
 	}
-	return 0, fmt.Errorf("Excepted an int64 value, got \"%v\"\n", i)
+	return 0, kindErrorf(ErrTypeMismatch, "Excepted an int64 value, got \"%v\"\n", i)
 }
 
 func interfaceToFloat64(i interface{}) (float64, error) {
@@ -28,28 +24,28 @@ func interfaceToFloat64(i interface{}) (float64, error) {
 	case int64:
 		return float64(i.(int64)), nil
 	}
-	return 0, fmt.Errorf("Excepted a float64 value, got \"%v\"\n", i)
+	return 0, kindErrorf(ErrTypeMismatch, "Excepted a float64 value, got \"%v\"\n", i)
 }
 
 func interfaceToString(i interface{}) (string, error) {
 	if b, ok := i.(string); ok {
 		return b, nil
 	}
-	return "", fmt.Errorf("Excepted a string value, got \"%v\"\n", i)
+	return "", kindErrorf(ErrTypeMismatch, "Excepted a string value, got \"%v\"\n", i)
 }
 
 func interfaceToObject(i interface{}) (map[string]interface{}, error) {
 	if b, ok := i.(map[string]interface{}); ok {
 		return b, nil
 	}
-	return nil, fmt.Errorf("Excepted an object value, got \"%v\"\n", i)
+	return nil, kindErrorf(ErrTypeMismatch, "Excepted an object value, got \"%v\"\n", i)
 }
 
 func interfaceToArray(i interface{}) ([]interface{}, error) {
 	if b, ok := i.([]interface{}); ok {
 		return b, nil
 	}
-	return nil, fmt.Errorf("Excepted an array value, got \"%v\"\n", i)
+	return nil, kindErrorf(ErrTypeMismatch, "Excepted an array value, got \"%v\"\n", i)
 }
 
 // ===========================================================================
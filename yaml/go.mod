@@ -0,0 +1,11 @@
+module github.com/Aoi-hosizora/jsonq/yaml
+
+go 1.13
+
+require (
+	github.com/Aoi-hosizora/ahlib v1.3.0
+	github.com/Aoi-hosizora/jsonq v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/Aoi-hosizora/jsonq => ../
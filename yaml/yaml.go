@@ -0,0 +1,17 @@
+// Package yaml creates json documents of jsonq from yaml, so that yaml could be queried by the same selectors.
+package yaml
+
+import (
+	"github.com/Aoi-hosizora/jsonq"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Create a JsonDocument from yaml, only the first document is used if there are multiple documents. The anchors, aliases
+// and merge keys ("<<") are resolved, and the values are converted by jsonq.NewJsonDocumentFromValue.
+func NewJsonDocument(data []byte) (*jsonq.JsonDocument, error) {
+	var v interface{}
+	if err := yamlv3.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return jsonq.NewJsonDocumentFromValue(v)
+}
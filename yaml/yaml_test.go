@@ -0,0 +1,64 @@
+package yaml
+
+import (
+	"github.com/Aoi-hosizora/ahlib/xtesting"
+	"github.com/Aoi-hosizora/jsonq"
+	"log"
+	"testing"
+)
+
+func handle(obj interface{}, err error) interface{} {
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return obj
+}
+
+func TestNewJsonDocument(t *testing.T) {
+	doc, err := NewJsonDocument([]byte(`
+base: &base
+  host: localhost
+  port: 8080
+  tags: [a, b]
+dev:
+  <<: *base
+  port: 8081
+prod: *base
+1: one
+true: yes
+created: 2001-12-14t21:59:43.10-05:00
+date: 2002-12-14
+quoted: "2002-12-14"
+big: 123456789
+float: 1e3
+inf: .inf
+nothing: ~
+`))
+	xtesting.Equal(t, err, nil)
+	jq := jsonq.NewJsonQuery(doc)
+	xtesting.Equal(t, handle(jq.StringBySelector("dev host")), "localhost")
+	xtesting.Equal(t, handle(jq.Int64BySelector("dev port")), int64(8081))
+	xtesting.Equal(t, handle(jq.Int64BySelector("prod port")), int64(8080))
+	xtesting.Equal(t, handle(jq.SelectBySelector("prod tags")), []interface{}{"a", "b"})
+	xtesting.Equal(t, handle(jq.StringBySelector("1")), "one")
+	xtesting.Equal(t, handle(jq.StringBySelector("true")), "yes")
+	xtesting.Equal(t, handle(jq.StringBySelector("created")), "2001-12-15T02:59:43.1Z")
+	xtesting.Equal(t, handle(jq.StringBySelector("date")), "2002-12-14T00:00:00Z")
+	xtesting.Equal(t, handle(jq.StringBySelector("quoted")), "2002-12-14")
+	xtesting.Equal(t, handle(jq.SelectBySelector("big")), 123456789.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("float")), 1000.)
+	xtesting.Equal(t, handle(jq.SelectBySelector("nothing")), nil)
+
+	// the same as json
+	jsonDoc, _ := jsonq.NewJsonDocument([]byte(`{"a": [1, {"b": "c"}], "d": null}`))
+	yamlDoc, err := NewJsonDocument([]byte("a:\n  - 1\n  - b: c\nd:\n"))
+	xtesting.Equal(t, err, nil)
+	xtesting.Equal(t, yamlDoc.Equal(jsonDoc), true)
+
+	_, err = NewJsonDocument([]byte("a: [1"))
+	xtesting.NotEqual(t, err, nil)
+	_, err = NewJsonDocument([]byte("abc"))
+	xtesting.NotEqual(t, err, nil)
+	_, err = NewJsonDocument([]byte("1: a\n\"1\": b\n"))
+	xtesting.NotEqual(t, err, nil)
+}